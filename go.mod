@@ -3,9 +3,15 @@ module github.com/sinclairtarget/michel
 go 1.25.4
 
 require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/alecthomas/chroma/v2 v2.27.0
 	github.com/fsnotify/fsnotify v1.9.0
 	github.com/sinclairtarget/libatrus-go v0.0.0-20250929114858-c6b44bf459de
+	golang.org/x/net v0.47.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
-require golang.org/x/sys v0.13.0 // indirect
+require (
+	github.com/dlclark/regexp2/v2 v2.2.1 // indirect
+	golang.org/x/sys v0.38.0 // indirect
+)
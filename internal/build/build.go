@@ -6,55 +6,140 @@
 * 	2. Load site page and asset metadata. If there is none, quit here.
 * 	3. Clean the target dir.
 * 	4. Load content metadata.
-* 	5. Load layouts.
-* 	6. Load partials.
-* 	7. For each site page:
-* 	       a. Load page template
-* 	       b. Parse it
-* 	       c. ExecuteTemplate() with layouts defined in the page frontmatter
-* 	8. For each site asset:
+* 	5. Load string tables for each language.
+* 	6. Load data files.
+* 	7. Load layouts.
+* 	8. Load partials.
+* 	9. For each language (just one if none are configured):
+* 	       a. For each site page built in the language:
+* 	              i. Clone the parsed partials and layouts used by the page
+* 	                 (parsed the first time the layouts are used)
+* 	              ii. Load page template and parse it into the clone
+* 	              iii. ExecuteTemplate() with layouts defined in the page
+* 	                   frontmatter (or the default layout), plus their parents
+* 	       b. If configured, write a search index.
+* 	10. For each site asset:
 * 	     Copy it to the target dir
-* 	9. Warn about content that wasn't rendered in any template.
+* 	11. Warn about content that wasn't rendered in any template.
+* 	12. Optionally, check the internal links in the built site.
+*
+* Each language is built under its own URL prefix. Assets are shared.
+*
+* A page or asset that fails to build doesn't stop the build, unless failing
+* fast. The failures are returned together as BuildErrors once every page and
+* asset has been processed, and steps 11 and 12 are skipped.
  */
 package build
 
 import (
 	"fmt"
-	"html/template"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/sinclairtarget/michel/internal/check"
 	"github.com/sinclairtarget/michel/internal/config"
 	"github.com/sinclairtarget/michel/internal/content"
+	"github.com/sinclairtarget/michel/internal/content/myst"
+	"github.com/sinclairtarget/michel/internal/data"
 	"github.com/sinclairtarget/michel/internal/site"
+	"github.com/sinclairtarget/michel/internal/util"
 )
 
-// Input directories
+// Default names of input directories
 const (
-	ContentDir  string = "content"
-	SiteDir            = "site"
-	LayoutsDir         = "layouts"
-	PartialsDir        = "partials"
+	ContentDir    string = "content"
+	SiteDir              = "site"
+	LayoutsDir           = "layouts"
+	PartialsDir          = "partials"
+	I18nDir              = "i18n"
+	DataDir              = "data"
+	ArchetypesDir        = "archetypes"
 )
 
 const DefaultOutputDir string = "public"
 
+// Options for a build.
+type Opts struct {
+	CheckLinks  bool         // Check internal links after building
+	Environment string       // Selects a config overlay, e.g. "production"
+	Source      string       // Site root; defaults to the working directory
+	ConfigPath  string       // Defaults to michel.yaml under the site root
+	FailFast    bool         // Stop at the first page or asset that fails
+	Logger      *slog.Logger // For warnings; defaults to slog.Default()
+}
+
 // Scope for a build.
 //
 // This is the relevant universe of inputs to a build.
 type scope struct {
-	config   config.Config
-	site     site.Site
-	corpus   content.Corpus
-	layouts  []Layout
-	partials []Partial
-	start    time.Time
+	config       config.Config
+	dirs         Dirs
+	site         site.Site
+	corpus       content.Corpus
+	layouts      map[string]Layout
+	partials     []Partial
+	templates    *templateCache // Parsed layouts and partials
+	partialCache *partialCache  // Output of partialCached
+	renderer     myst.Renderer
+	i18n         i18n
+	data         map[string]any
+	sites        map[string]site.Site // site localized for each language
+	start        time.Time
+	failFast     bool
+	errs         *BuildErrors // Failures so far, unless failing fast
+	report       *Report
+	logger       *slog.Logger // For warnings, which go in the report
+}
+
+// Records a failure to process a page or asset.
+//
+// Returns an error if the build should stop here.
+func (s scope) fail(kind string, path string, err error) error {
+	fileErr := FileError{Kind: kind, Path: path, Err: err}
+	if s.failFast {
+		return fileErr
+	}
+
+	slog.Debug("continuing after failure", "path", path, "error", err)
+	*s.errs = append(*s.errs, fileErr)
+	return nil
+}
+
+// Builds the site into the output directory.
+//
+// An empty output directory means the default, under the site root.
+func Build(outdir string, opts Opts) error {
+	_, err := BuildReport(outdir, opts)
+	return err
 }
 
-func Build(outdir string) error {
+// Builds the site, returning a report of what was built along with any error.
+//
+// Warnings logged during the build are included in the report.
+func BuildReport(outdir string, opts Opts) (Report, error) {
+	report := newReport()
+	start := time.Now()
+	outdir = OutputDir(outdir, opts)
+
+	opts.Logger = slog.New(reportHandler{
+		Handler: util.LoggerOrDefault(opts.Logger).Handler(),
+		report:  report,
+	})
+
+	err := build(outdir, opts, report)
+	if err != nil {
+		report.addErrors(err)
+	}
+
+	report.finish(start)
+	return *report, err
+}
+
+func build(outdir string, opts Opts, report *Report) error {
 	var (
 		scope scope
 		err   error
@@ -62,18 +147,28 @@ func Build(outdir string) error {
 
 	slog.Debug("beginning build")
 	scope.start = time.Now()
+	scope.failFast = opts.FailFast
+	scope.errs = &BuildErrors{}
+	scope.report = report
+	scope.logger = util.LoggerOrDefault(opts.Logger)
 
 	slog.Debug("loading config")
-	scope.config, err = config.Load()
+	scope.config, err = LoadConfig(opts)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %v", err)
+		return fmt.Errorf("failed to load config: %w", err)
 	}
+	scope.dirs = dirsFor(opts.Source, scope.config)
 
 	slog.Debug("loading site metadata")
-	scope.site, err = site.LoadSite(SiteDir, scope.config)
+	scope.site, err = site.LoadSite(
+		scope.dirs.Site,
+		scope.config,
+		scope.dirs.SiteLayers()[1:]...,
+	)
 	if err != nil {
 		return fmt.Errorf("failed to load site metadata: %v", err)
 	}
+	scope.site = scope.site.WithLogger(scope.logger)
 
 	if scope.site.NumPages()+scope.site.NumAssets() == 0 {
 		slog.Debug("build done because site is empty")
@@ -87,40 +182,66 @@ func Build(outdir string) error {
 	}
 
 	slog.Debug("loading content metadata")
-	scope.corpus, err = content.LoadCorpus(ContentDir)
+	scope.corpus, err = content.LoadCorpus(
+		scope.dirs.Content,
+		scope.config.LanguageCodes(),
+	)
 	if err != nil {
 		return fmt.Errorf("failed to load content metadata: %v", err)
 	}
+	if scope.config.Related != (config.RelatedConfig{}) {
+		content.SetRelatedWeights(scope.corpus, content.RelatedWeights{
+			Tags:    scope.config.Related.Tags,
+			Section: scope.config.Related.Section,
+			Text:    scope.config.Related.Text,
+		})
+	}
+
+	slog.Debug("loading string tables")
+	scope.i18n, err = loadI18n(
+		scope.dirs.I18n,
+		scope.config.Languages,
+		scope.logger,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to load string tables: %w", err)
+	}
+
+	slog.Debug("loading data files")
+	scope.data, err = data.LoadLayered(scope.dirs.DataLayers(), scope.logger)
+	if err != nil {
+		return fmt.Errorf("failed to load data files: %w", err)
+	}
 
 	slog.Debug("loading layouts")
-	scope.layouts, err = loadLayouts(LayoutsDir)
+	scope.layouts, err = loadLayouts(scope.dirs.LayoutsLayers())
 	if err != nil {
 		return fmt.Errorf("failed to load layouts: %w", err)
 	}
 
 	slog.Debug("loading partials")
-	scope.partials, err = loadPartials(PartialsDir)
+	scope.partials, err = loadPartials(scope.dirs.PartialsLayers())
 	if err != nil {
 		return fmt.Errorf("failed to load partials: %w", err)
 	}
+	scope.templates = newTemplateCache(scope.layouts, scope.partials)
+	scope.partialCache = newPartialCache()
 
-	slog.Debug("processing pages")
-	for page := range scope.site.Pages().All() {
-		targetPath := mapPage(page, outdir)
-		slog.Debug(
-			"processing page",
-			"key",
-			page.Key(),
-			"targetPath",
-			targetPath,
-		)
-		err = processPage(page, targetPath, scope)
+	languages := scope.config.Languages
+	if len(languages) == 0 {
+		languages = []config.Language{{}}
+	}
+	scope.sites = localizeSite(scope.site, scope.corpus, languages)
+
+	for _, lang := range languages {
+		slog.Debug("building language", "language", lang.Code)
+		langScope := scope
+		langScope.corpus = scope.corpus.InLanguage(lang.Code)
+		langScope.site = scope.sites[lang.Code]
+
+		err = buildLanguage(outdir, lang, langScope)
 		if err != nil {
-			return fmt.Errorf(
-				"failed to process page \"%s\": %w",
-				page.Filepath,
-				err,
-			)
+			return err
 		}
 	}
 
@@ -134,17 +255,39 @@ func Build(outdir string) error {
 			"targetPath",
 			targetPath,
 		)
+		assetStart := time.Now()
 		err = processAsset(asset, targetPath)
-		if err != nil {
-			return fmt.Errorf(
-				"failed to process asset \"%s\": %w",
-				asset.Filepath,
-				err,
-			)
+		if err == nil {
+			scope.report.Assets = append(scope.report.Assets, ReportFile{
+				Source:     asset.Filepath,
+				Target:     targetPath,
+				DurationMs: msSince(assetStart),
+			})
+		} else {
+			err = scope.fail("asset", asset.Filepath, err)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
-	content.ReportUnused(scope.corpus)
+	if len(*scope.errs) > 0 {
+		// Unused content and broken links would just be noise now
+		return scope.errs.sorted()
+	}
+
+	content.ReportUnused(scope.corpus, scope.logger)
+
+	if opts.CheckLinks {
+		slog.Debug("checking links")
+		err = check.Check(outdir, check.Opts{
+			BaseURL: scope.config.BaseURL,
+			Allow:   scope.config.Check.Allow,
+		})
+		if err != nil {
+			return fmt.Errorf("link check failed: %w", err)
+		}
+	}
 
 	elapsed := time.Now().Sub(scope.start)
 	slog.Debug(
@@ -159,6 +302,107 @@ func Build(outdir string) error {
 	return nil
 }
 
+// Builds the pages and search index for a single language.
+func buildLanguage(outdir string, lang config.Language, scope scope) error {
+	slog.Debug("indexing content labels")
+	resolver, err := newResolver(
+		scope.dirs.Content,
+		scope.dirs.Site,
+		scope.corpus,
+		scope.site,
+		scope.logger,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to index content labels: %w", err)
+	}
+	scope.renderer = newRenderer(scope.config, resolver, scope.logger)
+
+	slog.Debug("processing pages")
+	for page := range scope.site.Pages().All() {
+		targetPath := mapPage(page, outdir)
+		slog.Debug(
+			"processing page",
+			"key",
+			page.Key(),
+			"targetPath",
+			targetPath,
+		)
+		pageStart := time.Now()
+		layouts, err := layoutChain(page, scope.layouts)
+		if err == nil {
+			err = processPage(page, targetPath, layouts, scope)
+		}
+		if err == nil {
+			scope.report.Pages = append(scope.report.Pages, ReportFile{
+				Source:     page.Filepath,
+				Target:     targetPath,
+				ContentKey: page.ContentKey,
+				Language:   lang.Code,
+				Layouts:    layouts,
+				DurationMs: msSince(pageStart),
+			})
+		} else {
+			err = scope.fail("page", page.Filepath, err)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if scope.config.Search.Enabled {
+		slog.Debug("writing search index")
+		langOutdir := filepath.Join(outdir, filepath.FromSlash(lang.URLPrefix()))
+		targetPath := searchIndexPath(langOutdir, scope.config.Search)
+		err = writeSearchIndex(targetPath, scope)
+		if err != nil {
+			err = scope.fail("search index", targetPath, err)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns a copy of the site for each language, keyed by language code.
+//
+// A page that renders content is only built in the languages the content has
+// been translated into. If no languages are configured, every page is built.
+func localizeSite(
+	s site.Site,
+	corpus content.Corpus,
+	languages []config.Language,
+) map[string]site.Site {
+	sites := map[string]site.Site{}
+	for _, lang := range languages {
+		langCorpus := corpus.InLanguage(lang.Code)
+		sites[lang.Code] = s.InLanguage(lang, func(p site.PageMetadata) bool {
+			return lang.Code == "" || p.ContentKey == "" ||
+				langCorpus.Has(p.ContentKey)
+		})
+	}
+
+	return sites
+}
+
+// Returns the page as built in each of the other languages.
+func pageTranslations(page site.PageMetadata, scope scope) []site.PageMetadata {
+	translations := []site.PageMetadata{}
+	for _, lang := range scope.config.Languages {
+		if lang.Code == page.Language.Code {
+			continue
+		}
+
+		t, err := scope.sites[lang.Code].Pages().Get(page.Key())
+		if err == nil {
+			translations = append(translations, t)
+		}
+	}
+
+	return translations
+}
+
 func clean(dir string) error {
 	err := os.RemoveAll(dir)
 	if err != nil {
@@ -168,9 +412,11 @@ func clean(dir string) error {
 	return nil
 }
 
+// Renders the page, wrapped in the given chain of layouts, to the target path.
 func processPage(
 	metadata site.PageMetadata,
 	targetPath string,
+	layoutKeys []string,
 	scope scope,
 ) error {
 	// Set up output file
@@ -189,46 +435,49 @@ func processPage(
 	}
 	defer fout.Close()
 
-	// Set up root template and dot
-	rootTmpl := template.New("root")
+	// Set up template and dot
+	tmpl, err := scope.templates.forChain(layoutKeys)
+	if err != nil {
+		return err
+	}
 
 	dot := NewDot(
 		scope.config,
 		scope.corpus,
-		scope.site,
+		scope.site.ForPage(metadata.Key()),
+		scope.data,
 		metadata,
 		scope.start,
 	)
-	rootTmpl.Funcs(dot.funcMap(rootTmpl, fout))
+	dot.renderer = scope.renderer
+	dot.i18n = scope.i18n
+	dot.returns = &returnStack{}
+	dot.partialCache = scope.partialCache
+	dot.Page.translations = pageTranslations(metadata, scope)
+	out := &pageOutput{w: fout}
+	tmpl.Funcs(dot.funcMap(tmpl, out))
 
-	// Parse and add partials
-	rootTmpl, err = parsePartials(rootTmpl, scope.partials)
-	if err != nil {
-		return fmt.Errorf("failed to parse partials: %w", err)
-	}
-
-	// Parse and add layouts
-	layoutKeys := metadata.Layouts
-	tmpl, err := parseLayouts(rootTmpl, scope.layouts, layoutKeys)
+	page, err := site.LoadPage(metadata)
 	if err != nil {
-		return err // TODO: Handle layout not found
+		return err
 	}
 
-	// Parse page template
+	// Used to map template errors back to source files
 	tmplName := filepath.Base(metadata.Filepath)
-	tmpl = tmpl.New(tmplName)
-
-	page, err := site.LoadPage(metadata)
-	if err != nil {
-		return err
+	sources := maps.Clone(scope.templates.sources)
+	sources[tmplName] = templateSource{
+		path:      page.Filepath,
+		firstLine: page.TemplateLine,
 	}
+	funcs := scope.templates.funcs
 
-	tmpl, err = tmpl.Parse(page.TemplateText)
+	// Parse page template
+	pageTmpl, err := tmpl.New(tmplName).Parse(page.TemplateText)
 	if err != nil {
 		return fmt.Errorf(
 			"failed to parse template \"%s\": %w",
 			page.Filepath,
-			err,
+			newTemplateError(err, sources, funcs),
 		)
 	}
 
@@ -242,10 +491,12 @@ func processPage(
 		execName = tmplName
 	}
 
-	tmpl = template.Must(tmpl.Clone())
-	err = tmpl.ExecuteTemplate(fout, execName, dot)
+	err = pageTmpl.ExecuteTemplate(out, execName, dot)
 	if err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
+		return fmt.Errorf(
+			"failed to execute template: %w",
+			newTemplateError(err, sources, funcs),
+		)
 	}
 
 	return nil
@@ -0,0 +1,189 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/sinclairtarget/michel/internal/config"
+	"github.com/sinclairtarget/michel/internal/merrors"
+)
+
+// Input directories for a build.
+type Dirs struct {
+	Content    string
+	Site       string
+	Layouts    string
+	Partials   string
+	I18n       string
+	Data       string
+	Archetypes string      // Only used by "michel new", so not watched
+	Themes     []ThemeDirs // First wins
+}
+
+// Input directories from a theme.
+//
+// A theme is laid out like a site, with the default directory names, but
+// provides only site files, layouts, partials, and data. The site's own files
+// override a theme's files with the same key.
+type ThemeDirs struct {
+	Root     string
+	Site     string
+	Layouts  string
+	Partials string
+	Data     string
+}
+
+// Returns every input directory.
+func (d Dirs) All() []string {
+	all := []string{d.Content, d.Site, d.Layouts, d.Partials, d.I18n, d.Data}
+	for _, theme := range d.Themes {
+		all = append(all, theme.Site, theme.Layouts, theme.Partials, theme.Data)
+	}
+	return all
+}
+
+// Returns the site directories, the site's own first.
+func (d Dirs) SiteLayers() []string {
+	return d.layers(d.Site, func(t ThemeDirs) string { return t.Site })
+}
+
+// Returns the layouts directories, the site's own first.
+func (d Dirs) LayoutsLayers() []string {
+	return d.layers(d.Layouts, func(t ThemeDirs) string { return t.Layouts })
+}
+
+// Returns the partials directories, the site's own first.
+func (d Dirs) PartialsLayers() []string {
+	return d.layers(d.Partials, func(t ThemeDirs) string { return t.Partials })
+}
+
+// Returns the data directories, the site's own first.
+func (d Dirs) DataLayers() []string {
+	return d.layers(d.Data, func(t ThemeDirs) string { return t.Data })
+}
+
+func (d Dirs) layers(own string, theme func(ThemeDirs) string) []string {
+	layers := []string{own}
+	for _, t := range d.Themes {
+		layers = append(layers, theme(t))
+	}
+	return layers
+}
+
+// Returns the input directories under the site root, using the names in the
+// config or the defaults.
+func dirsFor(source string, c config.Config) Dirs {
+	dir := func(configured string, fallback string) string {
+		if configured == "" {
+			configured = fallback
+		}
+		return filepath.Join(source, configured)
+	}
+
+	themes := []ThemeDirs{}
+	for _, theme := range c.Themes {
+		root := theme
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(source, root)
+		}
+
+		themes = append(themes, ThemeDirs{
+			Root:     root,
+			Site:     filepath.Join(root, SiteDir),
+			Layouts:  filepath.Join(root, LayoutsDir),
+			Partials: filepath.Join(root, PartialsDir),
+			Data:     filepath.Join(root, DataDir),
+		})
+	}
+
+	return Dirs{
+		Content:    dir(c.Dirs.Content, ContentDir),
+		Site:       dir(c.Dirs.Site, SiteDir),
+		Layouts:    dir(c.Dirs.Layouts, LayoutsDir),
+		Partials:   dir(c.Dirs.Partials, PartialsDir),
+		I18n:       dir(c.Dirs.I18n, I18nDir),
+		Data:       dir(c.Dirs.Data, DataDir),
+		Archetypes: dir(c.Dirs.Archetypes, ArchetypesDir),
+		Themes:     themes,
+	}
+}
+
+// Loads the config for the build.
+//
+// Unless another config path is given, the config is loaded from the site
+// root.
+func LoadConfig(opts Opts) (config.Config, error) {
+	path := opts.ConfigPath
+	if path == "" {
+		path = filepath.Join(opts.Source, config.Filename)
+	}
+
+	c, err := config.Load(config.Opts{
+		Path:        path,
+		Environment: opts.Environment,
+		Logger:      opts.Logger,
+	})
+	if err != nil {
+		return c, err
+	}
+
+	return c, checkThemes(path, opts.Source, c)
+}
+
+// Returns an error if any theme named in the config loaded from the path isn't
+// a directory.
+//
+// Theme paths are relative to the site root, not the config file, so this
+// can't be checked when the config is loaded.
+func checkThemes(path string, source string, c config.Config) error {
+	for i, theme := range dirsFor(source, c).Themes {
+		info, err := os.Stat(theme.Root)
+		if err == nil && info.IsDir() {
+			continue
+		}
+
+		reason := "not a directory"
+		if errors.Is(err, fs.ErrNotExist) {
+			reason = "theme directory does not exist"
+		} else if err != nil {
+			reason = err.Error()
+		}
+
+		return merrors.InvalidConfigValueError{
+			Path:   path,
+			Key:    fmt.Sprintf("themes[%d]", i),
+			Value:  c.Themes[i],
+			Reason: reason,
+			Hint: "Theme paths are relative to the site root, " +
+				"e.g. \"themes/mytheme\".",
+		}
+	}
+
+	return nil
+}
+
+// Returns the output directory for the build.
+//
+// An empty directory means the default, under the site root, so that sites
+// built from elsewhere don't write over each other. Any other directory is
+// used as given, relative to the working directory.
+func OutputDir(outdir string, opts Opts) string {
+	if outdir == "" {
+		return filepath.Join(opts.Source, DefaultOutputDir)
+	}
+
+	return outdir
+}
+
+// Returns the input directories for the build.
+func InputDirs(opts Opts) (Dirs, error) {
+	c, err := LoadConfig(opts)
+	if err != nil {
+		return Dirs{}, err
+	}
+
+	return dirsFor(opts.Source, c), nil
+}
@@ -0,0 +1,76 @@
+package build_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/build"
+	"github.com/sinclairtarget/michel/internal/merrors"
+	"github.com/sinclairtarget/michel/internal/testutil"
+)
+
+// Themes are relative to the site root and must exist.
+func TestLoadConfigThemes(t *testing.T) {
+	tmpdir := testutil.TempFiles(t, map[string]string{
+		"site/michel.yaml":                 "themes: [../theme, ../no-such-theme]\n",
+		"theme/layouts/_default/page.html": "",
+	})
+
+	_, err := build.LoadConfig(build.Opts{Source: filepath.Join(tmpdir, "site")})
+
+	var configErr merrors.InvalidConfigValueError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("wanted invalid config value error, got %v", err)
+	}
+	if configErr.Key != "themes[1]" || configErr.Value != "../no-such-theme" {
+		t.Errorf(
+			"error incorrect; wanted themes[1] ../no-such-theme, got %s %s",
+			configErr.Key,
+			configErr.Value,
+		)
+	}
+}
+
+// The default output directory is under the site root, but an explicit one is
+// relative to the working directory.
+func TestOutputDir(t *testing.T) {
+	tmpdir := testutil.TempFiles(t, map[string]string{
+		"a/site/index.html": "---\nlayouts: []\n---\na",
+		"b/site/index.html": "---\nlayouts: []\n---\nb",
+	})
+	t.Chdir(tmpdir)
+
+	for _, source := range []string{"a", "b"} {
+		err := build.Build("", build.Opts{Source: source})
+		if err != nil {
+			t.Fatalf("failed to build %s: %v", source, err)
+		}
+	}
+
+	for _, source := range []string{"a", "b"} {
+		path := filepath.Join(tmpdir, source, "public", "index.html")
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read built page: %v", err)
+		}
+		if strings.TrimSpace(string(b)) != source {
+			t.Errorf("page for %s incorrect; got %s", source, b)
+		}
+	}
+
+	_, err := os.Stat(filepath.Join(tmpdir, build.DefaultOutputDir))
+	if err == nil {
+		t.Error("output written to the working directory")
+	}
+
+	outdir := build.OutputDir("out", build.Opts{Source: "a"})
+	if outdir != "out" {
+		t.Errorf(
+			"explicit output directory incorrect; wanted out, got %s",
+			outdir,
+		)
+	}
+}
@@ -3,8 +3,8 @@ package build
 import (
 	"fmt"
 	"html/template"
-	"io"
 	"iter"
+	"log/slog"
 	"slices"
 	"time"
 
@@ -21,7 +21,8 @@ import (
 // Content().
 type dotPage struct {
 	site.PageMetadata
-	corpus content.Corpus
+	corpus       content.Corpus
+	translations []site.PageMetadata
 }
 
 func (p dotPage) Content() (content.Content, error) {
@@ -39,6 +40,12 @@ func (p dotPage) ContentMaybe() (*content.Content, error) {
 	return p.corpus.GetMaybe(p.ContentKey)
 }
 
+// Returns this page as built in each of the other languages, in the order the
+// languages are configured.
+func (p dotPage) Translations() []site.PageMetadata {
+	return p.translations
+}
+
 type MichelInfo struct {
 	Version string
 }
@@ -51,15 +58,22 @@ type Dot struct {
 	Config  config.Config
 	Content content.Corpus
 	Site    site.Site
-	Page    dotPage   // Currently rendering page
-	Now     time.Time // Should be when the build started
+	Data    map[string]any // Loaded from files under data/
+	Page    dotPage        // Currently rendering page
+	Now     time.Time      // Should be when the build started
 	Michel  MichelInfo
+
+	renderer     myst.Renderer
+	i18n         i18n
+	returns      *returnStack  // For partialReturn
+	partialCache *partialCache // For partialCached; shared by all pages
 }
 
 func NewDot(
 	config config.Config,
 	corpus content.Corpus,
 	site site.Site,
+	data map[string]any,
 	page site.PageMetadata,
 	now time.Time,
 ) Dot {
@@ -67,6 +81,7 @@ func NewDot(
 		Config:  config,
 		Content: corpus,
 		Site:    site,
+		Data:    data,
 		Page:    dotPage{PageMetadata: page, corpus: corpus},
 		Now:     now,
 		Michel:  MichelInfo{Version: info.Version},
@@ -74,12 +89,51 @@ func NewDot(
 }
 
 // Defines the functions available in Michel templates.
-func (d Dot) funcMap(tmpl *template.Template, w io.Writer) template.FuncMap {
+//
+// Functions like partial need the template set being executed and the page
+// output. Templates are parsed with the func map for a zero Dot, nil template,
+// and nil output, then each page binds its own.
+func (d Dot) funcMap(tmpl *template.Template, out *pageOutput) template.FuncMap {
 	return template.FuncMap{
-		"renderHTML": myst.RenderHTML,
+		"renderHTML": d.renderer.RenderHTML,
 		"renderJSON": myst.RenderJSON,
-		"partial": func(key string, data any) error {
-			return executePartial(tmpl, w, key, data)
+		"include": func(key string) (template.HTML, error) {
+			c, err := d.Content.Get(key)
+			if err != nil {
+				return "", err
+			}
+			return d.renderer.RenderHTML(c.Root)
+		},
+		// The partial writes to the page itself. Something still has to be
+		// returned for the action to print, and an empty template.JS prints
+		// nothing in every context, where a nil error prints "null" in JS.
+		"partial": func(key string, data any) (template.JS, error) {
+			return "", executePartial(tmpl, out, key, data)
+		},
+		"partialString": func(key string, data any) (template.HTML, error) {
+			return capturePartial(tmpl, out, key, data)
+		},
+		"partialReturn": func(key string, data any) (any, error) {
+			return d.returns.call(key, func() error {
+				_, err := capturePartial(tmpl, out, key, data)
+				return err
+			})
+		},
+		"return": func(value any) (string, error) {
+			return d.returns.set(value)
+		},
+		"partialCached": func(
+			key string,
+			data any,
+			variants ...any,
+		) (template.HTML, error) {
+			lang := d.Page.Language.Code
+			return d.partialCache.get(key, lang, variants, func() (
+				template.HTML,
+				error,
+			) {
+				return capturePartial(tmpl, out, key, data)
+			})
 		},
 		"select":  selectAny,
 		"reject":  rejectAny,
@@ -91,17 +145,31 @@ func (d Dot) funcMap(tmpl *template.Template, w io.Writer) template.FuncMap {
 		"absURL": func(suffix string) string {
 			return site.AbsURL(suffix, d.Config.BaseURL)
 		},
+		"relLangURL": func(suffix string) string {
+			return site.LangRelURL(suffix, d.Page.Language, d.Config.BaseURL)
+		},
+		"absLangURL": func(suffix string) string {
+			return site.LangAbsURL(suffix, d.Page.Language, d.Config.BaseURL)
+		},
+		"i18n": func(id string) string {
+			return d.i18n.lookup(d.Page.Language.Code, id)
+		},
 	}
 }
 
-func executePartial(
-	tmpl *template.Template,
-	w io.Writer,
-	key string,
-	data any,
-) error {
-	execName := templateName("partials", key)
-	return tmpl.ExecuteTemplate(w, execName, data)
+// Returns a MyST renderer set up according to the site config.
+func newRenderer(
+	c config.Config,
+	r myst.Resolver,
+	logger *slog.Logger,
+) myst.Renderer {
+	return myst.Renderer{
+		Highlight:   c.Highlight.Enabled,
+		LineNumbers: c.Highlight.LineNumbers,
+		Math:        c.Math.Render,
+		Resolver:    r,
+		Logger:      logger,
+	}
 }
 
 func selectAny(field string, pattern string, seq any) iter.Seq[util.Keyed] {
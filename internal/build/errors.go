@@ -4,22 +4,131 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/sinclairtarget/michel/internal/merrors"
 )
 
+// A failure to process a single page or asset.
+type FileError struct {
+	Kind string // e.g. page, asset
+	Path string
+	Err  error
+}
+
+func (e FileError) Error() string {
+	return fmt.Sprintf("failed to process %s \"%s\": %v", e.Kind, e.Path, e.Err)
+}
+
+func (e FileError) Unwrap() error {
+	return e.Err
+}
+
+// Every page and asset that failed to build, sorted by path.
+type BuildErrors []FileError
+
+func (e BuildErrors) Error() string {
+	return fmt.Sprintf(
+		"%s in %s",
+		plural(len(e), "error"),
+		plural(len(e.byPath()), "file"),
+	)
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func (e BuildErrors) Unwrap() []error {
+	errs := []error{}
+	for _, fileErr := range e {
+		errs = append(errs, fileErr)
+	}
+	return errs
+}
+
+// Returns the errors grouped by path, in path order.
+func (e BuildErrors) byPath() [][]FileError {
+	groups := [][]FileError{}
+	for _, fileErr := range e {
+		n := len(groups)
+		if n > 0 && groups[n-1][0].Path == fileErr.Path {
+			groups[n-1] = append(groups[n-1], fileErr)
+		} else {
+			groups = append(groups, []FileError{fileErr})
+		}
+	}
+	return groups
+}
+
+// Returns the errors sorted by path, with duplicates removed.
+//
+// The same page can fail in the same way once for every language.
+func (e BuildErrors) sorted() BuildErrors {
+	sorted := BuildErrors{}
+	seen := map[[2]string]bool{}
+	for _, fileErr := range e {
+		id := [2]string{fileErr.Path, fileErr.Err.Error()}
+		if !seen[id] {
+			seen[id] = true
+			sorted = append(sorted, fileErr)
+		}
+	}
+
+	slices.SortStableFunc(sorted, func(a, b FileError) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return sorted
+}
+
 func PrintBuildError(err error) {
 	if err == nil {
 		return
 	}
 
+	var buildErrs BuildErrors
+	if errors.As(err, &buildErrs) {
+		fmt.Fprintf(os.Stderr, "Build failed with %v:\n", buildErrs)
+		for _, group := range buildErrs.byPath() {
+			fmt.Fprintln(os.Stderr)
+			fmt.Fprintf(os.Stderr, "%s:\n", group[0].Path)
+			for _, fileErr := range group {
+				printError(fileErr.Err)
+			}
+		}
+		return
+	}
+
+	fmt.Fprint(os.Stderr, "Error during build: ")
+	printError(err)
+}
+
+func printError(err error) {
+	var tmplErr TemplateError
+	if errors.As(err, &tmplErr) {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprint(os.Stderr, tmplErr.Snippet())
+		if tmplErr.Hint != "" {
+			fmt.Fprintln(os.Stderr)
+			fmt.Fprintln(os.Stderr, tmplErr.Hint)
+		}
+		return
+	}
+
 	var suggestErr merrors.SuggestError
 	if errors.As(err, &suggestErr) {
-		fmt.Fprintf(os.Stderr, "Error during build: %v\n", suggestErr)
-		fmt.Fprintf(os.Stderr, "  %v\n", err)
-		fmt.Println()
-		fmt.Println(suggestErr.Suggestion())
+		fmt.Fprintf(os.Stderr, "%v\n", suggestErr)
+		if err.Error() != suggestErr.Error() {
+			fmt.Fprintf(os.Stderr, "  %v\n", err)
+		}
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, suggestErr.Suggestion())
 	} else {
-		fmt.Fprintf(os.Stderr, "Error during build: %v\n", err)
+		fmt.Fprintf(os.Stderr, "%v\n", err)
 	}
 }
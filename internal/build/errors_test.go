@@ -0,0 +1,101 @@
+package build_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/build"
+)
+
+// Errors should be sorted by path, keeping the order they happened in for
+// each path, with the same failure in several languages reported once.
+func TestBuildErrorsSorted(t *testing.T) {
+	errs := build.BuildErrors{
+		{Kind: "page", Path: "site/b.html", Err: errors.New("first")},
+		{Kind: "asset", Path: "site/a.png", Err: errors.New("unreadable")},
+		{Kind: "page", Path: "site/b.html", Err: errors.New("second")},
+		// Same page failing again when building the next language
+		{Kind: "page", Path: "site/b.html", Err: errors.New("first")},
+		{Kind: "page", Path: "site/b.html", Err: errors.New("second")},
+		{Kind: "asset", Path: "site/a.png", Err: errors.New("unreadable")},
+	}
+
+	sorted := errs.Sorted()
+
+	expected := []string{
+		"site/a.png: unreadable",
+		"site/b.html: first",
+		"site/b.html: second",
+	}
+	if len(sorted) != len(expected) {
+		t.Fatalf("wrong number of errors; wanted %v, got %v", expected, sorted)
+	}
+	for i, fileErr := range sorted {
+		got := fileErr.Path + ": " + fileErr.Err.Error()
+		if got != expected[i] {
+			t.Errorf("error %d incorrect; wanted %s, got %s", i, expected[i], got)
+		}
+	}
+
+	expectedMessage := "3 errors in 2 files"
+	if sorted.Error() != expectedMessage {
+		t.Errorf(
+			"message incorrect; wanted \"%s\", got \"%s\"",
+			expectedMessage,
+			sorted.Error(),
+		)
+	}
+}
+
+// Sorting should not depend on the order errors happened in across paths.
+func TestBuildErrorsSortedDeterministic(t *testing.T) {
+	a := build.FileError{Path: "a.html", Err: errors.New("a")}
+	b := build.FileError{Path: "b.html", Err: errors.New("b")}
+	c := build.FileError{Path: "c.html", Err: errors.New("c")}
+
+	first := build.BuildErrors{c, a, b}.Sorted()
+	second := build.BuildErrors{b, c, a}.Sorted()
+	for i := range first {
+		if first[i].Path != second[i].Path {
+			t.Fatalf("order differs; got %v and %v", first, second)
+		}
+	}
+}
+
+func TestBuildErrorsByPath(t *testing.T) {
+	errs := build.BuildErrors{
+		{Path: "a.html", Err: errors.New("one")},
+		{Path: "a.html", Err: errors.New("two")},
+		{Path: "b.html", Err: errors.New("three")},
+	}
+
+	groups := errs.ByPath()
+	if len(groups) != 2 || len(groups[0]) != 2 || len(groups[1]) != 1 {
+		t.Fatalf("groups incorrect; got %v", groups)
+	}
+	if groups[0][0].Path != "a.html" || groups[1][0].Path != "b.html" {
+		t.Errorf("group paths incorrect; got %v", groups)
+	}
+
+	single := build.BuildErrors{{Path: "a.html", Err: errors.New("one")}}
+	if single.Error() != "1 error in 1 file" {
+		t.Errorf(
+			"message incorrect; wanted \"1 error in 1 file\", got \"%s\"",
+			single.Error(),
+		)
+	}
+}
+
+// Each error should still be reachable with errors.As.
+func TestBuildErrorsUnwrap(t *testing.T) {
+	target := build.TemplateError{Path: "b.html", Message: "boom"}
+	errs := build.BuildErrors{
+		{Path: "a.html", Err: errors.New("one")},
+		{Path: "b.html", Err: target},
+	}
+
+	var tmplErr build.TemplateError
+	if !errors.As(error(errs), &tmplErr) {
+		t.Errorf("template error not found in build errors")
+	}
+}
@@ -0,0 +1,73 @@
+package build
+
+import (
+	"log/slog"
+
+	"github.com/sinclairtarget/michel/internal/content"
+	"github.com/sinclairtarget/michel/internal/content/myst"
+	"github.com/sinclairtarget/michel/internal/site"
+)
+
+// Returns a resolver using the given label index, so that tests don't need to
+// parse content to find labels.
+func NewTestResolver(
+	contentDir string,
+	siteDir string,
+	corpus content.Corpus,
+	s site.Site,
+	labels map[string]string,
+	logger *slog.Logger,
+) myst.Resolver {
+	return resolver{
+		contentDir: contentDir,
+		siteDir:    siteDir,
+		corpus:     corpus,
+		site:       s,
+		labels:     labels,
+		pages:      pagesByContent(s),
+		logger:     logger,
+	}
+}
+
+func (e BuildErrors) Sorted() BuildErrors { return e.sorted() }
+
+func (e BuildErrors) ByPath() [][]FileError { return e.byPath() }
+
+func NewReport() *Report { return newReport() }
+
+func NewReportHandler(h slog.Handler, report *Report) slog.Handler {
+	return reportHandler{Handler: h, report: report}
+}
+
+func (r *Report) AddErrors(err error) { r.addErrors(err) }
+
+// Returns the layout chain for the page, using the layouts in the directory.
+func LayoutChain(page site.PageMetadata, layoutsDir string) ([]string, error) {
+	layouts, err := loadLayouts([]string{layoutsDir})
+	if err != nil {
+		return nil, err
+	}
+
+	return layoutChain(page, layouts)
+}
+
+// Where a template was loaded from, for NewTemplateError.
+type TemplateSource struct {
+	Path      string
+	FirstLine int
+}
+
+func NewTemplateError(
+	err error,
+	sources map[string]TemplateSource,
+	funcs []string,
+) error {
+	converted := map[string]templateSource{}
+	for name, source := range sources {
+		converted[name] = templateSource{
+			path:      source.Path,
+			firstLine: source.FirstLine,
+		}
+	}
+	return newTemplateError(err, converted, funcs)
+}
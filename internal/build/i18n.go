@@ -0,0 +1,85 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sinclairtarget/michel/internal/config"
+	"github.com/sinclairtarget/michel/internal/util"
+)
+
+// String tables for each language, used to translate the fixed text in
+// templates.
+//
+// The table for a language is loaded from i18n/<code>.yaml, which should map
+// string IDs to translated strings.
+type i18n struct {
+	tables   map[string]map[string]string // language code -> ID -> string
+	fallback string                       // default language code
+	logger   *slog.Logger                 // For missing translations
+}
+
+func loadI18n(
+	dir string,
+	languages []config.Language,
+	logger *slog.Logger,
+) (i18n, error) {
+	result := i18n{tables: map[string]map[string]string{}, logger: logger}
+	if len(languages) == 0 {
+		return result, nil
+	}
+	result.fallback = languages[0].Code
+
+	for _, lang := range languages {
+		path := filepath.Join(dir, lang.Code+".yaml")
+		b, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return result, err
+		}
+
+		table := map[string]string{}
+		err = yaml.Unmarshal(b, &table)
+		if err != nil {
+			return result, fmt.Errorf(
+				"failed to parse string table \"%s\": %w",
+				path,
+				err,
+			)
+		}
+
+		result.tables[lang.Code] = table
+	}
+
+	return result, nil
+}
+
+// Returns the string with the given ID in the given language.
+//
+// Falls back to the default language, then to the ID itself.
+func (t i18n) lookup(lang string, id string) string {
+	if s, ok := t.tables[lang][id]; ok {
+		return s
+	}
+
+	if s, ok := t.tables[t.fallback][id]; ok {
+		return s
+	}
+
+	util.LoggerOrDefault(t.logger).Warn(
+		"missing translation",
+		"id",
+		id,
+		"language",
+		lang,
+	)
+	return id
+}
@@ -0,0 +1,112 @@
+/*
+* Layouts for a page are chosen as follows:
+*
+*   1. If the page frontmatter lists layouts, those are used. An empty list
+*      ("layouts: []") means no layouts at all.
+*   2. Otherwise, the most specific default layout is used, if any exists:
+*        a. _default/DIR, for the directory of the page key or any ancestor
+*        b. _default/SECTION, for the directory of the page's content key or
+*           any ancestor
+*        c. _default/page
+*
+* A layout can name its own parent layout with a "layout" key in its YAML
+* frontmatter. Each layout is preceded by its ancestors, so a chain of layouts
+* doesn't have to be repeated on every page.
+ */
+package build
+
+import (
+	"maps"
+	"path"
+	"slices"
+
+	"github.com/sinclairtarget/michel/internal/merrors"
+	"github.com/sinclairtarget/michel/internal/site"
+	"github.com/sinclairtarget/michel/internal/util"
+)
+
+// Directory under the layouts directory holding default layouts.
+const defaultLayoutsDir = "_default"
+
+// Returns the keys of the layouts used by the page, outermost first.
+func layoutChain(
+	page site.PageMetadata,
+	layouts map[string]Layout,
+) ([]string, error) {
+	keys := page.Layouts
+	if keys == nil {
+		keys = defaultLayout(page, layouts)
+	}
+
+	chain := []string{}
+	for _, key := range keys {
+		ancestry, err := layoutAncestry(key, page.Filepath, layouts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ancestor := range ancestry {
+			if !slices.Contains(chain, ancestor) {
+				chain = append(chain, ancestor)
+			}
+		}
+	}
+
+	return chain, nil
+}
+
+// Returns the key of the most specific default layout for the page, or
+// nothing if there isn't one.
+func defaultLayout(page site.PageMetadata, layouts map[string]Layout) []string {
+	candidates := []string{}
+	for _, key := range []string{page.Key(), page.ContentKey} {
+		for dir := path.Dir(key); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			candidates = append(candidates, path.Join(defaultLayoutsDir, dir))
+		}
+	}
+	candidates = append(candidates, path.Join(defaultLayoutsDir, "page"))
+
+	for _, candidate := range candidates {
+		if _, ok := layouts[candidate]; ok {
+			return []string{candidate}
+		}
+	}
+
+	return nil
+}
+
+// Returns the key of the layout preceded by those of its ancestors.
+//
+// From is the path of the page or layout naming the layout, for errors.
+func layoutAncestry(
+	key string,
+	from string,
+	layouts map[string]Layout,
+) ([]string, error) {
+	ancestry := []string{}
+	for key != "" {
+		layout, ok := layouts[key]
+		if !ok {
+			didYouMean, _ := util.Closest(key, slices.Sorted(maps.Keys(layouts)))
+			return nil, merrors.LayoutNotFoundError{
+				Key:        key,
+				Path:       from,
+				DidYouMean: didYouMean,
+			}
+		}
+
+		if slices.Contains(ancestry, key) {
+			return nil, merrors.LayoutCycleError{
+				Path:  layout.path,
+				Chain: append(ancestry, key),
+			}
+		}
+
+		ancestry = append(ancestry, key)
+		from = layout.path
+		key = layout.parent
+	}
+
+	slices.Reverse(ancestry)
+	return ancestry, nil
+}
@@ -0,0 +1,193 @@
+package build_test
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/build"
+	"github.com/sinclairtarget/michel/internal/merrors"
+	"github.com/sinclairtarget/michel/internal/site"
+	"github.com/sinclairtarget/michel/internal/testutil"
+)
+
+func TestLayoutChain(t *testing.T) {
+	tmpdir := testutil.TempFiles(t, map[string]string{
+		"layouts/base.html":           "base",
+		"layouts/wide.html":           "---\nlayout: base\n---\nwide",
+		"layouts/_default/page.html":  "---\nlayout: base\n---\npage",
+		"layouts/_default/blog.html":  "---\nlayout: base\n---\nblog",
+		"layouts/_default/notes.html": "---\nlayout: base\n---\nnotes",
+	})
+	layoutsDir := filepath.Join(tmpdir, "layouts")
+	siteDir := filepath.Join(tmpdir, "site")
+
+	tests := []struct {
+		name        string
+		page        string // Path under the site directory
+		frontmatter string
+		expected    []string
+	}{
+		{
+			name:     "default page layout",
+			page:     "index.html",
+			expected: []string{"base", "_default/page"},
+		},
+		{
+			name:        "no layouts",
+			page:        "plain.html",
+			frontmatter: "layouts: []",
+			expected:    []string{},
+		},
+		{
+			name:     "directory default",
+			page:     "blog/2024/post.html",
+			expected: []string{"base", "_default/blog"},
+		},
+		{
+			name:        "section default",
+			page:        "misc/note.html",
+			frontmatter: "content: notes/first",
+			expected:    []string{"base", "_default/notes"},
+		},
+		{
+			name:        "directory default beats section default",
+			page:        "blog/note.html",
+			frontmatter: "content: notes/first",
+			expected:    []string{"base", "_default/blog"},
+		},
+		{
+			name:        "listed layouts share ancestors",
+			page:        "wide.html",
+			frontmatter: "layouts: [wide, _default/page]",
+			expected:    []string{"base", "wide", "_default/page"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page := loadTestPage(t, siteDir, test.page, test.frontmatter)
+
+			chain, err := build.LayoutChain(page, layoutsDir)
+			if err != nil {
+				t.Fatalf("failed to choose layouts: %v", err)
+			}
+
+			if !slices.Equal(chain, test.expected) {
+				t.Errorf(
+					"layouts incorrect; wanted %v, got %v",
+					test.expected,
+					chain,
+				)
+			}
+		})
+	}
+}
+
+// A page with no layouts in its frontmatter and no default layouts should be
+// rendered on its own.
+func TestLayoutChainNoDefault(t *testing.T) {
+	tmpdir := testutil.TempFiles(t, map[string]string{
+		"layouts/base.html": "base",
+	})
+	page := loadTestPage(t, filepath.Join(tmpdir, "site"), "index.html", "")
+
+	chain, err := build.LayoutChain(page, filepath.Join(tmpdir, "layouts"))
+	if err != nil {
+		t.Fatalf("failed to choose layouts: %v", err)
+	}
+
+	if len(chain) != 0 {
+		t.Errorf("layouts incorrect; wanted none, got %v", chain)
+	}
+}
+
+func TestLayoutChainErrors(t *testing.T) {
+	tmpdir := testutil.TempFiles(t, map[string]string{
+		"layouts/wide.html":    "wide",
+		"layouts/cycle/a.html": "---\nlayout: cycle/b\n---\na",
+		"layouts/cycle/b.html": "---\nlayout: cycle/a\n---\nb",
+		"layouts/broken.html":  "---\nlayout: nope\n---\nbroken",
+	})
+	layoutsDir := filepath.Join(tmpdir, "layouts")
+	siteDir := filepath.Join(tmpdir, "site")
+
+	t.Run("did you mean", func(t *testing.T) {
+		page := loadTestPage(t, siteDir, "typo.html", "layouts: [wdie]")
+
+		_, err := build.LayoutChain(page, layoutsDir)
+
+		var notFoundErr merrors.LayoutNotFoundError
+		if !errors.As(err, &notFoundErr) {
+			t.Fatalf("wanted layout not found error, got %v", err)
+		}
+		if notFoundErr.DidYouMean != "wide" ||
+			notFoundErr.Path != page.Filepath {
+			t.Errorf("error incorrect; got %+v", notFoundErr)
+		}
+	})
+
+	t.Run("missing parent", func(t *testing.T) {
+		page := loadTestPage(t, siteDir, "page.html", "layouts: [broken]")
+
+		_, err := build.LayoutChain(page, layoutsDir)
+
+		var notFoundErr merrors.LayoutNotFoundError
+		if !errors.As(err, &notFoundErr) {
+			t.Fatalf("wanted layout not found error, got %v", err)
+		}
+
+		brokenPath := filepath.Join(layoutsDir, "broken.html")
+		if notFoundErr.Key != "nope" || notFoundErr.Path != brokenPath {
+			t.Errorf("error incorrect; got %+v", notFoundErr)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		page := loadTestPage(t, siteDir, "page.html", "layouts: [cycle/a]")
+
+		_, err := build.LayoutChain(page, layoutsDir)
+
+		var cycleErr merrors.LayoutCycleError
+		if !errors.As(err, &cycleErr) {
+			t.Fatalf("wanted layout cycle error, got %v", err)
+		}
+
+		expected := []string{"cycle/a", "cycle/b", "cycle/a"}
+		if !slices.Equal(cycleErr.Chain, expected) {
+			t.Errorf(
+				"cycle incorrect; wanted %v, got %v",
+				expected,
+				cycleErr.Chain,
+			)
+		}
+	})
+}
+
+// Writes a page with the given frontmatter and loads its metadata.
+func loadTestPage(
+	t *testing.T,
+	siteDir string,
+	name string,
+	frontmatter string,
+) site.PageMetadata {
+	t.Helper()
+
+	text := "page"
+	if frontmatter != "" {
+		text = "---\n" + frontmatter + "\n---\n" + text
+	}
+	testutil.WriteFiles(t, siteDir, map[string]string{name: text})
+
+	page, err := site.LoadPageMetadata(
+		siteDir,
+		filepath.Join(siteDir, filepath.FromSlash(name)),
+		"",
+	)
+	if err != nil {
+		t.Fatalf("failed to load page: %v", err)
+	}
+
+	return page
+}
@@ -0,0 +1,311 @@
+package build
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sinclairtarget/michel/internal/config"
+	"github.com/sinclairtarget/michel/internal/content"
+	"github.com/sinclairtarget/michel/internal/site"
+	"github.com/sinclairtarget/michel/internal/util"
+)
+
+// Kinds of input that can be listed.
+var ListKinds = []string{"pages", "content", "assets", "layouts", "partials"}
+
+// A page, piece of content, asset, layout, or partial in the site.
+//
+// Only the fields that make sense for the kind are set.
+type ListEntry struct {
+	Key        string   `json:"key"`
+	Source     string   `json:"source"`
+	Target     string   `json:"target,omitempty"`
+	URL        string   `json:"url,omitempty"`
+	Language   string   `json:"language,omitempty"`
+	ContentKey string   `json:"contentKey,omitempty"`
+	Layouts    []string `json:"layouts,omitempty"`
+	Parent     string   `json:"parent,omitempty"` // Parent of a layout
+	Pages      []string `json:"pages,omitempty"`  // Pages bound to content
+	Date       string   `json:"date,omitempty"`
+	Draft      bool     `json:"draft,omitempty"`
+	Error      string   `json:"error,omitempty"` // e.g. a page's bad layout
+}
+
+// Columns shown in a table or CSV for each kind.
+var listColumns = map[string][]string{
+	"pages": {
+		"key", "language", "source", "target", "url", "content", "layouts",
+		"error",
+	},
+	"content":  {"key", "language", "source", "date", "draft", "pages"},
+	"assets":   {"key", "source", "target", "url"},
+	"layouts":  {"key", "source", "parent"},
+	"partials": {"key", "source"},
+}
+
+func (e ListEntry) column(name string) string {
+	switch name {
+	case "key":
+		return e.Key
+	case "source":
+		return e.Source
+	case "target":
+		return e.Target
+	case "url":
+		return e.URL
+	case "language":
+		return e.Language
+	case "content":
+		return e.ContentKey
+	case "layouts":
+		return strings.Join(e.Layouts, ",")
+	case "parent":
+		return e.Parent
+	case "pages":
+		return strings.Join(e.Pages, ",")
+	case "date":
+		return e.Date
+	case "draft":
+		return strconv.FormatBool(e.Draft)
+	case "error":
+		return e.Error
+	default:
+		panic(fmt.Sprintf("unknown list column \"%s\"", name))
+	}
+}
+
+// Returns the inputs of the given kind with keys matching the glob pattern,
+// sorted by key.
+//
+// Only metadata is loaded, so this is much faster than a build. Target paths
+// are under the given output directory, or the default one if it's empty. A
+// page whose layouts can't be worked out is still listed, with the error.
+func List(
+	kind string,
+	pattern string,
+	outdir string,
+	opts Opts,
+) ([]ListEntry, error) {
+	c, err := LoadConfig(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	dirs := dirsFor(opts.Source, c)
+	outdir = OutputDir(outdir, opts)
+
+	var entries []ListEntry
+	switch kind {
+	case "pages", "content", "assets":
+		entries, err = listSite(kind, outdir, dirs, c)
+	case "layouts":
+		entries, err = listLayouts(dirs)
+	case "partials":
+		entries, err = listPartials(dirs)
+	default:
+		return nil, fmt.Errorf(
+			"unknown kind \"%s\"; wanted one of %s",
+			kind,
+			strings.Join(ListKinds, ", "),
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []ListEntry{}
+	for _, entry := range entries {
+		if pattern == "" || util.MatchGlob(pattern, entry.Key) {
+			filtered = append(filtered, entry)
+		}
+	}
+
+	slices.SortStableFunc(filtered, func(a, b ListEntry) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return filtered, nil
+}
+
+func listSite(
+	kind string,
+	outdir string,
+	dirs Dirs,
+	c config.Config,
+) ([]ListEntry, error) {
+	s, err := site.LoadSite(dirs.Site, c, dirs.SiteLayers()[1:]...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load site metadata: %w", err)
+	}
+
+	entries := []ListEntry{}
+	if kind == "assets" {
+		for asset := range s.Assets().All() {
+			entries = append(entries, ListEntry{
+				Key:    asset.Key(),
+				Source: asset.Filepath,
+				Target: mapAsset(asset, outdir),
+				URL:    asset.RelURL(),
+			})
+		}
+		return entries, nil
+	}
+
+	corpus, err := content.LoadCorpus(dirs.Content, c.LanguageCodes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load content metadata: %w", err)
+	}
+
+	layouts, err := loadLayouts(dirs.LayoutsLayers())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load layouts: %w", err)
+	}
+
+	languages := c.Languages
+	if len(languages) == 0 {
+		languages = []config.Language{{}}
+	}
+	sites := localizeSite(s, corpus, languages)
+
+	for _, lang := range languages {
+		langSite := sites[lang.Code]
+
+		if kind == "pages" {
+			for page := range langSite.Pages().All() {
+				entry := ListEntry{
+					Key:        page.Key(),
+					Source:     page.Filepath,
+					Target:     mapPage(page, outdir),
+					URL:        page.RelURL(),
+					Language:   lang.Code,
+					ContentKey: page.ContentKey,
+				}
+
+				chain, err := layoutChain(page, layouts)
+				if err != nil {
+					entry.Error = err.Error()
+				} else {
+					entry.Layouts = chain
+				}
+
+				entries = append(entries, entry)
+			}
+			continue
+		}
+
+		bound := map[string][]string{}
+		for page := range langSite.Pages().All() {
+			if page.ContentKey != "" {
+				bound[page.ContentKey] = append(bound[page.ContentKey], page.Key())
+			}
+		}
+
+		for entry := range corpus.InLanguage(lang.Code).All() {
+			pages := bound[entry.Key()]
+			slices.Sort(pages)
+
+			date := "" // Content without a date falls back to year 1
+			if entry.Date.Year() > 1 {
+				date = entry.Date.Format("2006-01-02")
+			}
+
+			entries = append(entries, ListEntry{
+				Key:      entry.Key(),
+				Source:   entry.Filepath,
+				Language: lang.Code,
+				Pages:    pages,
+				Date:     date,
+				Draft:    entry.Draft,
+			})
+		}
+	}
+
+	return entries, nil
+}
+
+func listLayouts(dirs Dirs) ([]ListEntry, error) {
+	layouts, err := loadLayouts(dirs.LayoutsLayers())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load layouts: %w", err)
+	}
+
+	entries := []ListEntry{}
+	for _, key := range slices.Sorted(maps.Keys(layouts)) {
+		layout := layouts[key]
+		entries = append(entries, ListEntry{
+			Key:    key,
+			Source: layout.path,
+			Parent: layout.parent,
+		})
+	}
+	return entries, nil
+}
+
+func listPartials(dirs Dirs) ([]ListEntry, error) {
+	partials, err := loadPartials(dirs.PartialsLayers())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load partials: %w", err)
+	}
+
+	entries := []ListEntry{}
+	for _, partial := range partials {
+		entries = append(entries, ListEntry{
+			Key:    partial.key,
+			Source: partial.path,
+		})
+	}
+	return entries, nil
+}
+
+// Writes the entries in the given format: table, json, or csv.
+//
+// Tables and CSV files have the columns that make sense for the kind.
+func WriteList(
+	w io.Writer,
+	kind string,
+	format string,
+	entries []ListEntry,
+) error {
+	columns := listColumns[kind]
+
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+		for _, entry := range entries {
+			row := []string{}
+			for _, column := range columns {
+				row = append(row, entry.column(column))
+			}
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(columns)
+		for _, entry := range entries {
+			row := []string{}
+			for _, column := range columns {
+				row = append(row, entry.column(column))
+			}
+			cw.Write(row)
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf(
+			"unknown format \"%s\"; wanted table, json, or csv",
+			format,
+		)
+	}
+}
@@ -0,0 +1,174 @@
+package build_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/build"
+	"github.com/sinclairtarget/michel/internal/testutil"
+)
+
+// Writes a small site to a temporary directory, returning its options and
+// output directory.
+func listTestSite(t *testing.T) (build.Opts, string) {
+	t.Helper()
+
+	tmpdir := testutil.TempFiles(t, map[string]string{
+		"layouts/base.html":          "",
+		"layouts/_default/page.html": "---\nlayout: base\n---\n",
+		"site/index.html":            "",
+		"site/about.html":            "",
+		"site/post.html":             "---\ncontent: post\n---\n",
+		"site/broken.html":           "---\nlayouts: [missing]\n---\n",
+		"site/style.css":             "",
+		"content/post.md":            "---\ndate: 2025-01-02\ndraft: true\n---\n",
+		"content/unused.md":          "",
+	})
+
+	return build.Opts{Source: tmpdir}, filepath.Join(tmpdir, "public")
+}
+
+func TestListPages(t *testing.T) {
+	opts, outdir := listTestSite(t)
+
+	entries, err := build.List("pages", "", outdir, opts)
+	if err != nil {
+		t.Fatalf("failed to list pages: %v", err)
+	}
+
+	keys := []string{}
+	for _, entry := range entries {
+		keys = append(keys, entry.Key)
+	}
+	expected := []string{"about", "broken", "index", "post"}
+	if !slices.Equal(keys, expected) {
+		t.Fatalf("pages incorrect; wanted %v, got %v", expected, keys)
+	}
+
+	// A page with a bad layout should be listed with its error
+	broken := entries[1]
+	if !strings.Contains(broken.Error, `layout "missing"`) ||
+		broken.Layouts != nil {
+		t.Errorf("broken page incorrect; got %+v", broken)
+	}
+
+	post := entries[3]
+	if post.ContentKey != "post" ||
+		!slices.Equal(post.Layouts, []string{"base", "_default/page"}) ||
+		post.Target != filepath.Join(outdir, "post.html") ||
+		post.Error != "" {
+		t.Errorf("post page incorrect; got %+v", post)
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	opts, outdir := listTestSite(t)
+
+	tests := []struct {
+		kind     string
+		pattern  string
+		expected []string
+	}{
+		{"pages", "*o*", []string{"about", "broken", "post"}},
+		{"pages", "nope", []string{}},
+		{"content", "p*", []string{"post"}},
+		{"assets", "*.css", []string{"style.css"}},
+		{"layouts", "_default/*", []string{"_default/page"}},
+	}
+
+	for _, test := range tests {
+		entries, err := build.List(test.kind, test.pattern, outdir, opts)
+		if err != nil {
+			t.Fatalf("failed to list %s: %v", test.kind, err)
+		}
+
+		keys := []string{}
+		for _, entry := range entries {
+			keys = append(keys, entry.Key)
+		}
+		if !slices.Equal(keys, test.expected) {
+			t.Errorf(
+				"%s matching %s incorrect; wanted %v, got %v",
+				test.kind,
+				test.pattern,
+				test.expected,
+				keys,
+			)
+		}
+	}
+}
+
+func TestWriteList(t *testing.T) {
+	entries := []build.ListEntry{
+		{
+			Key:     "index",
+			Source:  "site/index.html",
+			Target:  "public/index.html",
+			URL:     "/index.html",
+			Layouts: []string{"base", "_default/page"},
+		},
+		{
+			Key:    "broken",
+			Source: "site/broken.html",
+			Target: "public/broken.html",
+			URL:    "/broken.html",
+			Error:  "bad, \"layout\"",
+		},
+	}
+
+	tests := map[string]string{
+		"table": "KEY     LANGUAGE  SOURCE            TARGET              " +
+			"URL           CONTENT  LAYOUTS             ERROR\n" +
+			"index             site/index.html   public/index.html   " +
+			"/index.html            base,_default/page  \n" +
+			"broken            site/broken.html  public/broken.html  " +
+			"/broken.html                               bad, \"layout\"\n",
+		"csv": "key,language,source,target,url,content,layouts,error\n" +
+			"index,,site/index.html,public/index.html,/index.html,," +
+			"\"base,_default/page\",\n" +
+			"broken,,site/broken.html,public/broken.html,/broken.html,,," +
+			"\"bad, \"\"layout\"\"\"\n",
+	}
+
+	for format, expected := range tests {
+		var b bytes.Buffer
+		err := build.WriteList(&b, "pages", format, entries)
+		if err != nil {
+			t.Fatalf("failed to write %s: %v", format, err)
+		}
+
+		if b.String() != expected {
+			t.Errorf(
+				"%s incorrect; wanted:\n%s\ngot:\n%s",
+				format,
+				expected,
+				b.String(),
+			)
+		}
+	}
+
+	var b bytes.Buffer
+	err := build.WriteList(&b, "pages", "json", entries)
+	if err != nil {
+		t.Fatalf("failed to write json: %v", err)
+	}
+
+	var decoded []build.ListEntry
+	err = json.Unmarshal(b.Bytes(), &decoded)
+	if err != nil {
+		t.Fatalf("failed to decode json: %v", err)
+	}
+	if len(decoded) != 2 || decoded[1].Error != entries[1].Error ||
+		!slices.Equal(decoded[0].Layouts, entries[0].Layouts) {
+		t.Errorf("json incorrect; got %s", b.String())
+	}
+
+	err = build.WriteList(&b, "pages", "xml", entries)
+	if err == nil {
+		t.Error("wanted error for unknown format")
+	}
+}
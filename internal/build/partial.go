@@ -0,0 +1,158 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Where a page's output goes while it is rendered.
+//
+// Partials write straight to the page, just like the template calling them,
+// so html/template never sees (and re-escapes) their output. Functions that
+// return a partial's output as a value capture it by pointing the output at a
+// buffer for the duration of the call.
+type pageOutput struct {
+	w io.Writer
+}
+
+func (o *pageOutput) Write(p []byte) (int, error) {
+	return o.w.Write(p)
+}
+
+// Calls the function, returning everything written to the output meanwhile
+// instead of writing it to the page.
+func (o *pageOutput) capture(f func() error) (string, error) {
+	var sb strings.Builder
+	prev := o.w
+	o.w = &sb
+	defer func() { o.w = prev }()
+
+	err := f()
+	return sb.String(), err
+}
+
+// Executes the partial, writing its output to the writer.
+func executePartial(
+	tmpl *template.Template,
+	w io.Writer,
+	key string,
+	data any,
+) error {
+	execName := templateName("partials", key)
+	return tmpl.ExecuteTemplate(w, execName, data)
+}
+
+// Executes the partial, returning its output.
+//
+// The output has already been escaped by the partial, so it is returned as
+// trusted HTML.
+func capturePartial(
+	tmpl *template.Template,
+	out *pageOutput,
+	key string,
+	data any,
+) (template.HTML, error) {
+	output, err := out.capture(func() error {
+		return executePartial(tmpl, out, key, data)
+	})
+	return template.HTML(output), err
+}
+
+// Values passed to "return" by partials called with partialReturn, innermost
+// call last.
+//
+// Go templates can't stop executing early, so the last value passed to
+// "return" wins.
+type returnStack struct {
+	values   []any
+	returned []bool
+}
+
+// Calls the function, returning the value it passes to "return".
+func (s *returnStack) call(key string, f func() error) (any, error) {
+	s.values = append(s.values, nil)
+	s.returned = append(s.returned, false)
+	defer func() {
+		s.values = s.values[:len(s.values)-1]
+		s.returned = s.returned[:len(s.returned)-1]
+	}()
+
+	err := f()
+	if err != nil {
+		return nil, err
+	}
+
+	top := len(s.values) - 1
+	if !s.returned[top] {
+		return nil, fmt.Errorf(
+			"partial \"%s\" called with partialReturn did not use return",
+			key,
+		)
+	}
+
+	return s.values[top], nil
+}
+
+// Sets the value returned by the innermost partialReturn call.
+//
+// Returns an empty string so nothing is written to the output.
+func (s *returnStack) set(value any) (string, error) {
+	if len(s.values) == 0 {
+		return "", errors.New("return used outside a partial called with " +
+			"partialReturn")
+	}
+
+	top := len(s.values) - 1
+	s.values[top] = value
+	s.returned[top] = true
+	return "", nil
+}
+
+// Output of partials rendered with partialCached, shared by every page in the
+// build.
+type partialCache struct {
+	outputs map[string]template.HTML
+}
+
+func newPartialCache() *partialCache {
+	return &partialCache{outputs: map[string]template.HTML{}}
+}
+
+// Returns the cached output for the partial, language, and variant
+// arguments, rendering it with the function the first time.
+//
+// The data passed to the partial isn't part of the cache key: it's usually the
+// whole page, which would make every call a miss. Instead, callers must pass at
+// least one variant, and the variants must cover everything in the data that
+// the partial's output depends on. A partial that's the same on every page can
+// pass a constant, e.g. {{ partialCached "footer" . "footer" }}.
+func (c *partialCache) get(
+	key string,
+	lang string,
+	variants []any,
+	render func() (template.HTML, error),
+) (template.HTML, error) {
+	if len(variants) == 0 {
+		return "", fmt.Errorf(
+			"partialCached \"%s\" needs at least one variant to cache by",
+			key,
+		)
+	}
+
+	// %#v so that e.g. "a b" and "a", "b" are different keys
+	cacheKey := fmt.Sprintf("%s\x00%s\x00%#v", key, lang, variants)
+	if output, ok := c.outputs[cacheKey]; ok {
+		return output, nil
+	}
+
+	output, err := render()
+	if err != nil {
+		return "", err
+	}
+
+	c.outputs[cacheKey] = output
+	return output, nil
+}
@@ -0,0 +1,185 @@
+package build_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/build"
+	"github.com/sinclairtarget/michel/internal/testutil"
+)
+
+// Builds a site with the given index page and partials, returning the built
+// index page.
+//
+// If the page fails to build, returns the page's error.
+func buildIndex(
+	t *testing.T,
+	index string,
+	partials map[string]string,
+) (string, error) {
+	t.Helper()
+
+	files := map[string]string{
+		"site/index.html": "---\nlayouts: []\n---\n" + index,
+	}
+	for key, text := range partials {
+		files["partials/"+key+".html"] = text
+	}
+	tmpdir := testutil.TempFiles(t, files)
+	outdir := filepath.Join(tmpdir, "public")
+
+	err := build.Build(outdir, build.Opts{Source: tmpdir})
+	var buildErrs build.BuildErrors
+	if errors.As(err, &buildErrs) && len(buildErrs) == 1 {
+		return "", buildErrs[0].Err
+	}
+	if err != nil {
+		return "", err
+	}
+
+	d, err := os.ReadFile(filepath.Join(outdir, "index.html"))
+	if err != nil {
+		t.Fatalf("failed to read page: %v", err)
+	}
+
+	return strings.TrimSpace(string(d)), nil
+}
+
+// Partials write straight to the page, so their output isn't escaped again
+// for the context they're called in.
+func TestPartialOutput(t *testing.T) {
+	index := `<script>{{ partial "js" . }}</script>` +
+		`<style>{{ partial "css" . }}</style>` +
+		`<p>{{ partial "p" . }}</p>`
+	page, err := buildIndex(t, index, map[string]string{
+		"js":  `var a = "b";`,
+		"css": `body { font-family: "Serif"; }`,
+		"p":   `<em>"quoted"</em>`,
+	})
+	if err != nil {
+		t.Fatalf("failed to build: %v", err)
+	}
+
+	expected := `<script>var a = "b";</script>` +
+		`<style>body { font-family: "Serif"; }</style>` +
+		`<p><em>"quoted"</em></p>`
+	if page != expected {
+		t.Errorf("page incorrect; wanted %s, got %s", expected, page)
+	}
+}
+
+func TestPartialString(t *testing.T) {
+	index := `{{ $s := partialString "p" . }}[{{ $s }}]`
+	page, err := buildIndex(t, index, map[string]string{
+		"p": " <b>x</b> ",
+	})
+	if err != nil {
+		t.Fatalf("failed to build: %v", err)
+	}
+
+	expected := "[ <b>x</b> ]"
+	if page != expected {
+		t.Errorf("page incorrect; wanted %s, got %s", expected, page)
+	}
+}
+
+func TestPartialReturnNested(t *testing.T) {
+	index := `{{ partialReturn "outer" 2 }}`
+	page, err := buildIndex(t, index, map[string]string{
+		"outer": `{{ $inner := partialReturn "inner" . }}` +
+			`ignored{{ return (printf "outer(%v)" $inner) }}`,
+		"inner": `ignored{{ return (printf "inner(%v)" .) }}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to build: %v", err)
+	}
+
+	expected := "outer(inner(2))"
+	if page != expected {
+		t.Errorf("page incorrect; wanted %s, got %s", expected, page)
+	}
+}
+
+func TestPartialReturnErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		index    string
+		partial  string
+		expected string // In the error message
+	}{
+		{
+			name:     "return in page",
+			index:    `{{ return 1 }}`,
+			expected: "return used outside a partial called with partialReturn",
+		},
+		{
+			name:     "return in plain partial",
+			index:    `{{ partial "p" . }}`,
+			partial:  `{{ return 1 }}`,
+			expected: "return used outside a partial called with partialReturn",
+		},
+		{
+			name:     "no return",
+			index:    `{{ partialReturn "p" . }}`,
+			partial:  `no value`,
+			expected: `partial "p" called with partialReturn did not use return`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := buildIndex(t, test.index, map[string]string{
+				"p": test.partial,
+			})
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+
+			if !strings.Contains(err.Error(), test.expected) {
+				t.Errorf(
+					"error incorrect; wanted %s, got %v",
+					test.expected,
+					err,
+				)
+			}
+		})
+	}
+}
+
+func TestPartialCached(t *testing.T) {
+	// The data isn't part of the cache key, so the second call gets the first
+	// call's output.
+	index := `{{ partialCached "p" "a" "v" }}` +
+		`{{ partialCached "p" "b" "v" }}` +
+		`{{ partialCached "p" "c" "w" }}` +
+		`{{ partialCached "p" "d" "x y" }}` +
+		`{{ partialCached "p" "e" "x" "y" }}`
+	page, err := buildIndex(t, index, map[string]string{
+		"p": "{{ . }}",
+	})
+	if err != nil {
+		t.Fatalf("failed to build: %v", err)
+	}
+
+	expected := "aacde"
+	if page != expected {
+		t.Errorf("page incorrect; wanted %s, got %s", expected, page)
+	}
+}
+
+func TestPartialCachedNoVariants(t *testing.T) {
+	_, err := buildIndex(t, `{{ partialCached "p" . }}`, map[string]string{
+		"p": "p",
+	})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+
+	expected := `partialCached "p" needs at least one variant`
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("error incorrect; wanted %s, got %v", expected, err)
+	}
+}
@@ -8,7 +8,11 @@ import (
 
 func mapPage(page site.PageMetadata, targetDir string) string {
 	targetFilepath := page.Key() + ".html"
-	return filepath.Join(targetDir, targetFilepath)
+	return filepath.Join(
+		targetDir,
+		filepath.FromSlash(page.Language.URLPrefix()),
+		targetFilepath,
+	)
 }
 
 func mapAsset(asset site.AssetMetadata, targetDir string) string {
@@ -0,0 +1,159 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// A structured record of a build, for tools such as CI to consume.
+type Report struct {
+	Pages    []ReportFile  `json:"pages"`
+	Assets   []ReportFile  `json:"assets"`
+	Warnings []ReportIssue `json:"warnings"`
+	Errors   []ReportIssue `json:"errors"`
+	Totals   ReportTotals  `json:"totals"`
+}
+
+// A page or asset written to the output directory.
+type ReportFile struct {
+	Source     string   `json:"source"`
+	Target     string   `json:"target"`
+	ContentKey string   `json:"contentKey,omitempty"`
+	Language   string   `json:"language,omitempty"`
+	Layouts    []string `json:"layouts,omitempty"`
+	DurationMs float64  `json:"durationMs"`
+}
+
+// A warning or error, with the file and line it concerns if known.
+type ReportIssue struct {
+	Message string            `json:"message"`
+	Path    string            `json:"path,omitempty"`
+	Line    int               `json:"line,omitempty"`
+	Attrs   map[string]string `json:"attrs,omitempty"` // Any other details
+}
+
+type ReportTotals struct {
+	Pages      int     `json:"pages"`
+	Assets     int     `json:"assets"`
+	Warnings   int     `json:"warnings"`
+	Errors     int     `json:"errors"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+func newReport() *Report {
+	return &Report{
+		Pages:    []ReportFile{},
+		Assets:   []ReportFile{},
+		Warnings: []ReportIssue{},
+		Errors:   []ReportIssue{},
+	}
+}
+
+// Writes the report as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// Adds an entry for every error in the error returned by the build.
+func (r *Report) addErrors(err error) {
+	var buildErrs BuildErrors
+	if !errors.As(err, &buildErrs) {
+		r.Errors = append(r.Errors, issueFor("", err))
+		return
+	}
+
+	for _, fileErr := range buildErrs {
+		r.Errors = append(r.Errors, issueFor(fileErr.Path, fileErr.Err))
+	}
+}
+
+func (r *Report) finish(start time.Time) {
+	r.Totals = ReportTotals{
+		Pages:      len(r.Pages),
+		Assets:     len(r.Assets),
+		Warnings:   len(r.Warnings),
+		Errors:     len(r.Errors),
+		DurationMs: msSince(start),
+	}
+}
+
+func issueFor(path string, err error) ReportIssue {
+	issue := ReportIssue{Message: err.Error(), Path: path}
+
+	var tmplErr TemplateError
+	if errors.As(err, &tmplErr) {
+		issue.Path = tmplErr.Path
+		issue.Line = tmplErr.Line
+	}
+
+	return issue
+}
+
+func msSince(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
+
+// Logging handler that adds warnings to the report before passing them on.
+//
+// The build hands a logger using this handler to everything that can warn, so
+// each build's warnings end up in its own report.
+type reportHandler struct {
+	slog.Handler
+	report *Report
+	attrs  []slog.Attr
+}
+
+func (h reportHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= slog.LevelWarn {
+		issue := ReportIssue{Message: record.Message}
+		addAttr := func(attr slog.Attr) bool {
+			switch attr.Key {
+			case "path":
+				issue.Path = attr.Value.String()
+			case "line":
+				if attr.Value.Kind() == slog.KindInt64 {
+					issue.Line = int(attr.Value.Int64())
+					break
+				}
+				fallthrough
+			default:
+				if issue.Attrs == nil {
+					issue.Attrs = map[string]string{}
+				}
+				issue.Attrs[attr.Key] = fmt.Sprint(attr.Value.Any())
+			}
+			return true
+		}
+
+		for _, attr := range h.attrs {
+			addAttr(attr)
+		}
+		record.Attrs(addAttr)
+		h.report.Warnings = append(h.report.Warnings, issue)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h reportHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return reportHandler{
+		Handler: h.Handler.WithAttrs(attrs),
+		report:  h.report,
+		attrs:   append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...),
+	}
+}
+
+func (h reportHandler) WithGroup(name string) slog.Handler {
+	return reportHandler{
+		Handler: h.Handler.WithGroup(name),
+		report:  h.report,
+		attrs:   h.attrs,
+	}
+}
@@ -0,0 +1,107 @@
+package build_test
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/build"
+)
+
+// Warnings, and only warnings, should be added to the report, with their path
+// and line pulled out of their attributes.
+func TestReportHandler(t *testing.T) {
+	report := build.NewReport()
+	handler := slog.NewTextHandler(io.Discard, nil)
+	logger := slog.New(build.NewReportHandler(handler, report))
+
+	logger.Info("not a warning", "path", "a.md")
+	logger.Warn("duplicate label", "label", "intro", "path", "a.md", "line", 3)
+	logger.With("menu", "main").WithGroup("g").Error(
+		"broken menu",
+		"identifier",
+		"docs",
+	)
+
+	expected := []build.ReportIssue{
+		{
+			Message: "duplicate label",
+			Path:    "a.md",
+			Line:    3,
+			Attrs:   map[string]string{"label": "intro"},
+		},
+		{
+			Message: "broken menu",
+			Attrs:   map[string]string{"menu": "main", "identifier": "docs"},
+		},
+	}
+	if len(report.Warnings) != len(expected) {
+		t.Fatalf(
+			"wrong number of warnings; wanted %d, got %+v",
+			len(expected),
+			report.Warnings,
+		)
+	}
+	for i, issue := range report.Warnings {
+		if !equalIssues(issue, expected[i]) {
+			t.Errorf(
+				"warning %d incorrect; wanted %+v, got %+v",
+				i,
+				expected[i],
+				issue,
+			)
+		}
+	}
+}
+
+// Every file error in a failed build should be reported, using the location
+// of template errors where there is one.
+func TestReportAddErrors(t *testing.T) {
+	report := build.NewReport()
+	report.AddErrors(build.BuildErrors{
+		{Kind: "asset", Path: "site/a.png", Err: errors.New("unreadable")},
+		{
+			Kind: "page",
+			Path: "site/b.html",
+			Err: build.TemplateError{
+				Path:    "layouts/base.html",
+				Line:    12,
+				Column:  -1,
+				Message: "boom",
+			},
+		},
+	})
+	report.AddErrors(errors.New("failed to load config"))
+
+	expected := []build.ReportIssue{
+		{Message: "unreadable", Path: "site/a.png"},
+		{Message: "layouts/base.html:12: boom", Path: "layouts/base.html", Line: 12},
+		{Message: "failed to load config"},
+	}
+	if len(report.Errors) != len(expected) {
+		t.Fatalf(
+			"wrong number of errors; wanted %d, got %+v",
+			len(expected),
+			report.Errors,
+		)
+	}
+	for i, issue := range report.Errors {
+		if !equalIssues(issue, expected[i]) {
+			t.Errorf(
+				"error %d incorrect; wanted %+v, got %+v",
+				i,
+				expected[i],
+				issue,
+			)
+		}
+	}
+}
+
+func equalIssues(a build.ReportIssue, b build.ReportIssue) bool {
+	return a.Message == b.Message &&
+		a.Path == b.Path &&
+		a.Line == b.Line &&
+		maps.Equal(a.Attrs, b.Attrs)
+}
@@ -0,0 +1,239 @@
+package build
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sinclairtarget/michel/internal/content"
+	"github.com/sinclairtarget/michel/internal/merrors"
+	"github.com/sinclairtarget/michel/internal/site"
+	"github.com/sinclairtarget/michel/internal/util"
+)
+
+// Resolves references between content files to the URLs of the pages that
+// render them.
+//
+// Implements myst.Resolver.
+type resolver struct {
+	contentDir string
+	siteDir    string
+	corpus     content.Corpus
+	site       site.Site
+	labels     map[string]string            // label -> content key
+	pages      map[string]site.PageMetadata // content key -> page
+	logger     *slog.Logger
+}
+
+func newResolver(
+	contentDir string,
+	siteDir string,
+	corpus content.Corpus,
+	s site.Site,
+	logger *slog.Logger,
+) (resolver, error) {
+	labels, err := content.IndexLabels(corpus, logger)
+	if err != nil {
+		return resolver{}, err
+	}
+
+	return resolver{
+		contentDir: contentDir,
+		siteDir:    siteDir,
+		corpus:     corpus,
+		site:       s,
+		labels:     labels,
+		pages:      pagesByContent(s),
+		logger:     logger,
+	}, nil
+}
+
+// Returns a map from content key to the page that renders the content.
+//
+// If more than one page renders the same content, the first page by key is
+// used.
+func pagesByContent(s site.Site) map[string]site.PageMetadata {
+	pages := map[string]site.PageMetadata{}
+
+	lookup := map[string]site.PageMetadata{}
+	for page := range s.Pages().All() {
+		lookup[page.Key()] = page
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(lookup)) {
+		page := lookup[key]
+		if _, ok := pages[page.ContentKey]; page.ContentKey != "" && !ok {
+			pages[page.ContentKey] = page
+		}
+	}
+
+	return pages
+}
+
+func (r resolver) ResolveRef(source string, label string) (string, error) {
+	key, ok := r.labels[label]
+	if !ok {
+		return "", merrors.UnresolvedReferenceError{
+			Path:   source,
+			Target: label,
+			Kind:   "label",
+		}
+	}
+
+	page, ok := r.pages[key]
+	if !ok {
+		return "", merrors.UnrenderedContentError{
+			Path:       source,
+			Target:     label,
+			ContentKey: key,
+		}
+	}
+
+	return page.RelURL() + "#" + url.PathEscape(label), nil
+}
+
+// Resolves a link to another file, given as a path relative to the source
+// file.
+//
+// Links to content files resolve to the URL of the page that renders the
+// content. Links to files in the site directory resolve to the URL of the
+// page or asset. Anything else (e.g. a link to a directory or to a path in the
+// output) resolves to an empty string so that it is left alone, with a warning
+// if it names a file in the content or site directories that doesn't exist,
+// or looks like a link to a source file.
+func (r resolver) ResolveLink(source string, target string) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", nil
+	}
+
+	path := filepath.Join(filepath.Dir(source), filepath.FromSlash(u.Path))
+
+	var resolved string
+	if key, ok := r.contentKey(path); ok && r.corpus.Has(key) {
+		page, ok := r.pages[key]
+		if !ok {
+			r.logger.Warn(
+				"link to content that no page renders",
+				"path",
+				source,
+				"target",
+				target,
+				"content",
+				key,
+			)
+			return "", nil
+		}
+
+		resolved = page.RelURL()
+	} else if rel, ok := relativePath(r.siteDir, path); ok {
+		pageKey := util.KeyFromPath(r.siteDir, path)
+		if asset, err := r.site.Assets().Get(rel); err == nil {
+			resolved = asset.RelURL()
+		} else if page, err := r.site.Pages().Get(pageKey); err == nil {
+			resolved = page.RelURL()
+		}
+	}
+
+	if resolved == "" {
+		switch {
+		case r.isMissing(path):
+			r.logger.Warn(
+				"link to file that doesn't exist",
+				"path",
+				source,
+				"target",
+				target,
+			)
+		case r.isSourceFile(path):
+			r.logger.Warn(
+				"link to source file that doesn't resolve",
+				"path",
+				source,
+				"target",
+				target,
+			)
+		}
+		return "", nil
+	}
+
+	if u.Fragment != "" {
+		resolved += "#" + u.EscapedFragment()
+	}
+	return resolved, nil
+}
+
+// Returns true if the path is inside the content or site directories but
+// doesn't exist.
+func (r resolver) isMissing(path string) bool {
+	_, inContent := relativePath(r.contentDir, path)
+	_, inSite := relativePath(r.siteDir, path)
+	if !inContent && !inSite {
+		return false
+	}
+
+	_, err := os.Stat(path)
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// Returns true if the path looks like it names a content or site file, rather
+// than something in the output.
+func (r resolver) isSourceFile(path string) bool {
+	if filepath.Ext(path) == ".md" {
+		return true
+	}
+
+	_, inContent := relativePath(r.contentDir, path)
+	_, inSite := relativePath(r.siteDir, path)
+	if !inContent && !inSite {
+		return false
+	}
+
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// Returns the key of the content at the path, if the path is inside the
+// content directory.
+//
+// Links always resolve to content in the current language, even if they name
+// a file in another language.
+func (r resolver) contentKey(path string) (string, bool) {
+	key, ok := relativeKey(r.contentDir, path)
+	if !ok {
+		return "", false
+	}
+
+	key, _ = content.SplitLanguage(key, r.corpus.Languages())
+	return key, true
+}
+
+// Returns the path relative to the directory, if the path is inside it.
+func relativePath(dir string, path string) (string, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return "", false
+	}
+
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return rel, true
+}
+
+// Returns the key for the path relative to the directory, if the path is
+// inside it.
+func relativeKey(dir string, path string) (string, bool) {
+	if _, ok := relativePath(dir, path); !ok {
+		return "", false
+	}
+
+	return util.KeyFromPath(dir, path), true
+}
@@ -0,0 +1,209 @@
+package build_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/build"
+	"github.com/sinclairtarget/michel/internal/config"
+	"github.com/sinclairtarget/michel/internal/content"
+	"github.com/sinclairtarget/michel/internal/content/myst"
+	"github.com/sinclairtarget/michel/internal/merrors"
+	"github.com/sinclairtarget/michel/internal/site"
+	"github.com/sinclairtarget/michel/internal/testutil"
+)
+
+// Returns a resolver for a small site, along with the path of the content
+// directory.
+func newTestResolver(
+	t *testing.T,
+	logger *slog.Logger,
+) (myst.Resolver, string) {
+	t.Helper()
+
+	tmpdir := testutil.TempFiles(t, map[string]string{
+		"content/guides/intro.md": "---\ntitle: Intro\n---\n",
+		"content/guides/setup.md": "---\ntitle: Setup\n---\n",
+		"content/orphan.md":       "---\ntitle: Orphan\n---\n",
+		"site/guides/intro.html":  "---\ncontent: guides/intro\n---\n",
+		"site/guides/setup.html":  "---\ncontent: guides/setup\n---\n",
+		"site/about.html.tmpl":    "about",
+		"site/img/logo.png":       "",
+	})
+	contentDir := filepath.Join(tmpdir, "content")
+	siteDir := filepath.Join(tmpdir, "site")
+
+	corpus, err := content.LoadCorpus(contentDir, nil)
+	if err != nil {
+		t.Fatalf("failed to load corpus: %v", err)
+	}
+
+	s, err := site.LoadSite(siteDir, config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to load site: %v", err)
+	}
+
+	labels := map[string]string{
+		"intro":       "guides/intro",
+		"install":     "guides/setup",
+		"orphan-note": "orphan",
+	}
+	r := build.NewTestResolver(contentDir, siteDir, corpus, s, labels, logger)
+	return r, contentDir
+}
+
+func TestResolveRef(t *testing.T) {
+	r, contentDir := newTestResolver(t, slog.Default())
+	source := filepath.Join(contentDir, "guides", "intro.md")
+
+	url, err := r.ResolveRef(source, "install")
+	if err != nil {
+		t.Fatalf("failed to resolve reference: %v", err)
+	}
+
+	expected := "/guides/setup.html#install"
+	if url != expected {
+		t.Errorf("resolved URL incorrect; wanted %s, got %s", expected, url)
+	}
+}
+
+// A reference to a label no content defines should be an error pointing at
+// the content with the reference.
+func TestResolveRefUnresolved(t *testing.T) {
+	r, contentDir := newTestResolver(t, slog.Default())
+	source := filepath.Join(contentDir, "guides", "intro.md")
+
+	_, err := r.ResolveRef(source, "instal")
+
+	var unresolvedErr merrors.UnresolvedReferenceError
+	if !errors.As(err, &unresolvedErr) {
+		t.Fatalf("expected unresolved reference error, got %v", err)
+	}
+
+	if unresolvedErr.Kind != "label" ||
+		unresolvedErr.Target != "instal" ||
+		unresolvedErr.Path != source {
+		t.Errorf("error incorrect; got %+v", unresolvedErr)
+	}
+}
+
+// A reference to a label in content that no page renders has nowhere to
+// link to.
+func TestResolveRefUnrendered(t *testing.T) {
+	r, contentDir := newTestResolver(t, slog.Default())
+	source := filepath.Join(contentDir, "guides", "intro.md")
+
+	_, err := r.ResolveRef(source, "orphan-note")
+
+	var unrenderedErr merrors.UnrenderedContentError
+	if !errors.As(err, &unrenderedErr) {
+		t.Fatalf("expected unrendered content error, got %v", err)
+	}
+
+	if unrenderedErr.ContentKey != "orphan" {
+		t.Errorf(
+			"content key incorrect; wanted orphan, got %s",
+			unrenderedErr.ContentKey,
+		)
+	}
+}
+
+func TestResolveLink(t *testing.T) {
+	r, contentDir := newTestResolver(t, slog.Default())
+	source := filepath.Join(contentDir, "guides", "intro.md")
+
+	tests := map[string]string{
+		"setup.md":                   "/guides/setup.html",
+		"setup.md#install":           "/guides/setup.html#install",
+		"../../site/img/logo.png":    "/img/logo.png",
+		"../../site/about.html.tmpl": "/about.html",
+		// Left alone, though some are warned about
+		"../about/":           "",
+		"other.html":          "",
+		"../../site/img/":     "",
+		"../orphan.md":        "",
+		"missing.md":          "",
+		"../../site/nope.png": "",
+	}
+
+	for target, expected := range tests {
+		resolved, err := r.ResolveLink(source, target)
+		if err != nil {
+			t.Errorf("failed to resolve link to \"%s\": %v", target, err)
+			continue
+		}
+
+		if resolved != expected {
+			t.Errorf(
+				"resolved link to \"%s\" incorrect; wanted \"%s\", got \"%s\"",
+				target,
+				expected,
+				resolved,
+			)
+		}
+	}
+}
+
+// Links that don't resolve are left alone, but links to files in the content
+// or site directories that don't exist, and links that look like they were
+// meant to name a source file, should be warned about.
+func TestResolveLinkWarnings(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	r, contentDir := newTestResolver(t, logger)
+	source := filepath.Join(contentDir, "guides", "intro.md")
+
+	missing := "link to file that doesn't exist"
+	unresolved := "link to source file that doesn't resolve"
+	unrendered := "link to content that no page renders"
+	tests := map[string]string{
+		"../orphan.md":            unrendered,
+		"missing.md":              missing,
+		"other.html":              missing,
+		"../about/":               missing,
+		"../../site/nope.png":     missing,
+		"../../site/img/nope.png": missing,
+		"../orphan.md.bak":        missing,
+		"intro.md#x":              "",
+		"../../site/img/":         "",
+		"../../public/about.html": "",
+	}
+
+	for target, expected := range tests {
+		logs.Reset()
+		_, err := r.ResolveLink(source, target)
+		if err != nil {
+			t.Fatalf("failed to resolve link to \"%s\": %v", target, err)
+		}
+
+		warned := logs.String()
+		if expected == "" && warned != "" ||
+			!strings.Contains(warned, expected) {
+			t.Errorf(
+				"warning for link to \"%s\" incorrect; wanted %s, got %s",
+				target,
+				expected,
+				warned,
+			)
+		}
+	}
+
+	// Source files that exist but don't resolve get a different warning
+	testutil.WriteFiles(t, contentDir, map[string]string{"notes.txt": ""})
+	logs.Reset()
+	_, err := r.ResolveLink(source, "../notes.txt")
+	if err != nil {
+		t.Fatalf("failed to resolve link: %v", err)
+	}
+	if !strings.Contains(logs.String(), unresolved) {
+		t.Errorf(
+			"warning incorrect; wanted %s, got %s",
+			unresolved,
+			logs.String(),
+		)
+	}
+}
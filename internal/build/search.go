@@ -0,0 +1,123 @@
+package build
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sinclairtarget/michel/internal/config"
+	"github.com/sinclairtarget/michel/internal/content"
+	"github.com/sinclairtarget/michel/internal/search"
+	"github.com/sinclairtarget/michel/internal/util"
+)
+
+const defaultSearchOutput = "search.json"
+
+// Returns the path the search index is written to under the output directory.
+func searchIndexPath(outdir string, conf config.SearchConfig) string {
+	output := conf.Output
+	if output == "" {
+		output = defaultSearchOutput
+	}
+	return filepath.Join(outdir, filepath.FromSlash(output))
+}
+
+// Writes a search index covering the configured selection of content to the
+// target path.
+//
+// Only content rendered by a page is indexed, since otherwise there would be
+// no URL to link to. Drafts are never indexed.
+func writeSearchIndex(targetPath string, scope scope) error {
+	conf := scope.config.Search
+	pages := pagesByContent(scope.site)
+
+	entries := slices.SortedFunc(
+		scope.corpus.All(),
+		func(a, b content.Entry) int {
+			return strings.Compare(a.Key(), b.Key())
+		},
+	)
+
+	docs := []search.Document{}
+	for _, entry := range entries {
+		if entry.Draft {
+			continue
+		}
+		if !includeInSearch(entry.Key(), conf.Include, conf.Exclude) {
+			continue
+		}
+
+		page, ok := pages[entry.Key()]
+		if !ok {
+			slog.Debug(
+				"not indexing content without a page",
+				"key",
+				entry.Key(),
+			)
+			continue
+		}
+
+		c, err := content.LoadContent(entry.Metadata)
+		if err != nil {
+			return err
+		}
+
+		text, err := c.Root.PlainText()
+		if err != nil {
+			return err
+		}
+
+		headings, err := c.Root.Headings()
+		if err != nil {
+			return err
+		}
+
+		docs = append(docs, search.Document{
+			Title:    c.Title,
+			URL:      page.RelURL(),
+			Headings: headings,
+			Text:     text,
+			Tags:     c.Tags,
+		})
+	}
+
+	err := os.MkdirAll(filepath.Dir(targetPath), 0o755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(targetPath)
+	if err != nil {
+		return fmt.Errorf(
+			"failed to create file at \"%s\": %w",
+			targetPath,
+			err,
+		)
+	}
+	defer f.Close()
+
+	return search.NewIndex(docs, conf.Inverted).Write(f)
+}
+
+// Returns true if the key matches any of the include patterns (or there are
+// none) and doesn't match any of the exclude patterns.
+func includeInSearch(key string, include []string, exclude []string) bool {
+	included := len(include) == 0
+	for _, pattern := range include {
+		if util.MatchGlob(pattern, key) {
+			included = true
+			break
+		}
+	}
+
+	for _, pattern := range exclude {
+		if util.MatchGlob(pattern, key) {
+			return false
+		}
+	}
+
+	return included
+}
@@ -9,7 +9,9 @@
 * layout or partial is the filepath to that layout or partial relative to the
 * layouts/ or partials/ directory respectively, excluding the file extension.
 *
-* Layouts are rendered in the order they are listed in the YAML frontmatter.
+* Layouts are rendered in the order they are listed in the YAML frontmatter,
+* outermost first. Later layouts (and finally the page) override the blocks
+* defined by earlier ones. See layout.go for how a page's layouts are chosen.
 *
 * All Michel templates have access to certain Michel data structures exposed
 * via the '.' (dot).
@@ -19,8 +21,13 @@ package build
 import (
 	"fmt"
 	"html/template"
+	"log/slog"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 
+	"github.com/sinclairtarget/michel/internal/load"
 	"github.com/sinclairtarget/michel/internal/util"
 )
 
@@ -30,6 +37,7 @@ type stencil struct {
 	key          string // unique id for layout
 	path         string // path it was loaded from
 	templateText string
+	firstLine    int // line in the file where templateText begins
 }
 
 type Partial struct {
@@ -42,6 +50,11 @@ func (p Partial) templateName() string {
 
 type Layout struct {
 	stencil
+	parent string // key of parent layout, if any
+}
+
+type layoutFrontmatter struct {
+	Layout string // Key of the parent layout
 }
 
 func (l Layout) templateName() string {
@@ -54,10 +67,11 @@ func templateName(namespace string, key string) string {
 	return namespace + "/" + key
 }
 
-func loadPartials(dir string) ([]Partial, error) {
+// Loads every partial from the layered partials directories.
+func loadPartials(dirs []string) ([]Partial, error) {
 	partials := []Partial{}
 
-	stencils, err := loadStencils(dir)
+	stencils, err := loadStencils(dirs)
 	if err != nil {
 		return partials, err
 	}
@@ -68,17 +82,34 @@ func loadPartials(dir string) ([]Partial, error) {
 	return partials, nil
 }
 
-func loadLayouts(dir string) ([]Layout, error) {
-	layouts := []Layout{}
+// Loads every layout from the layered layouts directories, by key.
+//
+// Unlike partials, layouts can have YAML frontmatter naming a parent layout.
+func loadLayouts(dirs []string) (map[string]Layout, error) {
+	layouts := map[string]Layout{}
 
-	stencils, err := loadStencils(dir)
+	files, err := util.LayeredFiles(dirs, util.KeyFromPath)
 	if err != nil {
 		return layouts, err
 	}
 
-	for _, s := range stencils {
-		layouts = append(layouts, Layout{s})
+	for _, file := range files {
+		result, err := load.ReadFile[layoutFrontmatter](file.Path, load.Opts{})
+		if err != nil {
+			return nil, err
+		}
+
+		layouts[file.Key] = Layout{
+			stencil: stencil{
+				key:          file.Key,
+				path:         file.Path,
+				templateText: result.Text,
+				firstLine:    result.TextLine,
+			},
+			parent: result.Frontmatter.Layout,
+		}
 	}
+
 	return layouts, nil
 }
 
@@ -99,22 +130,15 @@ func parsePartials(
 }
 
 // Parse and add named layouts to association.
+//
+// The keys should come from layoutChain(), so every layout exists.
 func parseLayouts(
 	tmpl *template.Template,
-	layouts []Layout,
+	layouts map[string]Layout,
 	keys []string,
 ) (*template.Template, error) {
-	lookup := map[string]Layout{}
-	for _, layout := range layouts {
-		lookup[layout.key] = layout
-	}
-
 	for _, key := range keys {
-		layout, ok := lookup[key]
-		if !ok {
-			return nil, fmt.Errorf("layout \"%s\" not found", key)
-		}
-
+		layout := layouts[key]
 		tmpl = tmpl.New(layout.templateName())
 		_, err := tmpl.Parse(layout.templateText)
 		if err != nil {
@@ -125,28 +149,148 @@ func parseLayouts(
 	return tmpl, nil
 }
 
-func loadStencils(dir string) ([]stencil, error) {
+func loadStencils(dirs []string) ([]stencil, error) {
 	stencils := []stencil{}
 
-	seq, finish := util.WalkFiles(dir)
-	for path := range seq {
-		b, err := os.ReadFile(path)
+	files, err := util.LayeredFiles(dirs, util.KeyFromPath)
+	if err != nil {
+		return stencils, err
+	}
+
+	for _, file := range files {
+		b, err := os.ReadFile(file.Path)
 		if err != nil {
 			return nil, err
 		}
 
 		stencil := stencil{
-			key:          util.KeyFromPath(dir, path),
-			path:         path,
+			key:          file.Key,
+			path:         file.Path,
 			templateText: string(b),
+			firstLine:    1,
 		}
 		stencils = append(stencils, stencil)
 	}
 
-	err := finish()
+	return stencils, nil
+}
+
+// Layouts and partials, parsed once per build and shared by every page.
+//
+// Layouts define the same blocks as one another, so each chain of layouts gets
+// its own template set. Pages clone the set for their chain before adding their
+// own template; the cached sets are never executed.
+type templateCache struct {
+	layouts  map[string]Layout
+	partials []Partial
+	sources  map[string]templateSource
+	funcs    []string
+	base     *template.Template // Just the partials
+	baseErr  error
+	chains   map[string]chainEntry // By layout keys
+}
+
+type chainEntry struct {
+	tmpl *template.Template
+	err  error
+}
+
+func newTemplateCache(
+	layouts map[string]Layout,
+	partials []Partial,
+) *templateCache {
+	return &templateCache{
+		layouts:  layouts,
+		partials: partials,
+		sources:  templateSources(layouts, partials),
+		funcs:    templateFuncNames(Dot{}.funcMap(nil, nil)),
+		chains:   map[string]chainEntry{},
+	}
+}
+
+// Returns a copy of the template set for the chain of layouts, ready for a
+// page template to be added.
+//
+// Parse errors are cached too, so a broken layout or partial is only parsed
+// once but still fails every page that uses it.
+func (c *templateCache) forChain(keys []string) (*template.Template, error) {
+	if c.base == nil && c.baseErr == nil {
+		root := template.New("root").Funcs(Dot{}.funcMap(nil, nil))
+		c.base, c.baseErr = parsePartials(root, c.partials)
+		if c.baseErr != nil {
+			c.baseErr = fmt.Errorf(
+				"failed to parse partials: %w",
+				newTemplateError(c.baseErr, c.sources, c.funcs),
+			)
+		}
+	}
+	if c.baseErr != nil {
+		return nil, c.baseErr
+	}
+
+	id := strings.Join(keys, "\x00")
+	entry, ok := c.chains[id]
+	if !ok {
+		slog.Debug("parsing layouts", "layouts", keys)
+		entry.tmpl, entry.err = c.parseChain(keys)
+		c.chains[id] = entry
+	}
+	if entry.err != nil {
+		return nil, entry.err
+	}
+
+	return entry.tmpl.Clone()
+}
+
+func (c *templateCache) parseChain(keys []string) (*template.Template, error) {
+	tmpl, err := c.base.Clone()
 	if err != nil {
-		return stencils, err
+		return nil, err
 	}
 
-	return stencils, nil
+	tmpl, err = parseLayouts(tmpl, c.layouts, keys)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to parse layouts: %w",
+			newTemplateError(err, c.sources, c.funcs),
+		)
+	}
+
+	return tmpl, nil
+}
+
+// Returns where each layout and partial was loaded from, by template name.
+func templateSources(
+	layouts map[string]Layout,
+	partials []Partial,
+) map[string]templateSource {
+	sources := map[string]templateSource{}
+	for _, layout := range layouts {
+		sources[layout.templateName()] = templateSource{
+			path:      layout.path,
+			firstLine: layout.firstLine,
+		}
+	}
+	for _, partial := range partials {
+		sources[partial.templateName()] = templateSource{
+			path:      partial.path,
+			firstLine: partial.firstLine,
+		}
+	}
+
+	return sources
+}
+
+// Functions every Go template has, in addition to those in our func map.
+var builtinFuncs = []string{
+	"and", "call", "html", "index", "slice", "js", "len", "not", "or", "print",
+	"printf", "println", "urlquery", "eq", "ge", "gt", "le", "lt", "ne",
+}
+
+// Returns the sorted names of all functions available in templates.
+func templateFuncNames(funcMap template.FuncMap) []string {
+	names := slices.Collect(maps.Keys(funcMap))
+	names = append(names, builtinFuncs...)
+	slices.Sort(names)
+	return names
 }
@@ -0,0 +1,263 @@
+package build
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sinclairtarget/michel/internal/config"
+	"github.com/sinclairtarget/michel/internal/content"
+	"github.com/sinclairtarget/michel/internal/site"
+	"github.com/sinclairtarget/michel/internal/util"
+)
+
+// Number of lines shown before and after the offending line.
+const contextLines = 2
+
+// Where a template was loaded from.
+type templateSource struct {
+	path      string
+	firstLine int // Line in the file where the template text begins
+}
+
+// A template parse or execution error, mapped back to the file the template
+// was loaded from.
+type TemplateError struct {
+	Path    string
+	Line    int    // Line in the file
+	Column  int    // Byte offset in the line, or -1 if unknown
+	Message string // With template names replaced by file paths
+	Hint    string // Suggested fix, if we can guess one
+	Err     error
+}
+
+func (e TemplateError) Error() string {
+	if e.Column >= 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", e.Path, e.Line, e.Column+1, e.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.Path, e.Line, e.Message)
+}
+
+func (e TemplateError) Unwrap() error {
+	return e.Err
+}
+
+func (e TemplateError) Suggestion() string {
+	return e.Hint
+}
+
+// Returns the offending line with a few lines of context and a caret pointing
+// at the column, or an empty string if the file can't be read.
+func (e TemplateError) Snippet() string {
+	b, err := os.ReadFile(e.Path)
+	if err != nil {
+		return ""
+	}
+
+	lines := strings.Split(string(b), "\n")
+	if e.Line < 1 || e.Line > len(lines) {
+		return ""
+	}
+
+	first := max(1, e.Line-contextLines)
+	last := min(len(lines), e.Line+contextLines)
+	width := len(strconv.Itoa(last))
+
+	var sb strings.Builder
+	for n := first; n <= last; n++ {
+		line := lines[n-1]
+		fmt.Fprintf(&sb, "  %*d | %s\n", width, n, line)
+
+		if n == e.Line && e.Column >= 0 && e.Column <= len(line) {
+			// Keep tabs so the caret lines up
+			indent := strings.Map(func(r rune) rune {
+				if r == '\t' {
+					return r
+				}
+				return ' '
+			}, line[:e.Column])
+			fmt.Fprintf(&sb, "  %*s | %s^\n", width, "", indent)
+		}
+	}
+
+	return sb.String()
+}
+
+// Matches the location prefix Go puts on template errors, e.g.
+// "template: layouts/base:3:12: ".
+var templateLocationPattern = regexp.MustCompile(
+	`template: ([^:\s]+):(\d+)(?::(\d+))?: `,
+)
+
+// Wraps a template error as a TemplateError.
+//
+// Errors from nested template calls (e.g. partials) contain several locations;
+// the innermost one is used. If the error has no location we recognize, it is
+// returned unchanged.
+func newTemplateError(
+	err error,
+	sources map[string]templateSource,
+	funcs []string,
+) error {
+	msg := err.Error()
+
+	matches := templateLocationPattern.FindAllStringSubmatchIndex(msg, -1)
+	if len(matches) == 0 {
+		return err
+	}
+	match := matches[len(matches)-1]
+
+	name := msg[match[2]:match[3]]
+	source, ok := sources[name]
+	if !ok {
+		return err
+	}
+
+	line, _ := strconv.Atoi(msg[match[4]:match[5]])
+	column := -1
+	if match[6] >= 0 {
+		column, _ = strconv.Atoi(msg[match[6]:match[7]])
+	}
+
+	message := msg[match[1]:]
+	for _, other := range slices.Sorted(maps.Keys(sources)) {
+		message = strings.ReplaceAll(
+			message,
+			strconv.Quote(other),
+			strconv.Quote(sources[other].path),
+		)
+	}
+
+	return TemplateError{
+		Path:    source.path,
+		Line:    source.firstLine + line - 1,
+		Column:  column,
+		Message: message,
+		Hint:    templateHint(msg[match[1]:], sources, funcs),
+		Err:     err,
+	}
+}
+
+var (
+	unknownFieldPattern = regexp.MustCompile(
+		`can't evaluate field (\w+) in type \*?([\w.]+)`,
+	)
+	unknownFuncPattern    = regexp.MustCompile(`function "(\w+)" not defined`)
+	missingPartialPattern = regexp.MustCompile(
+		`"partials/([^"]+)" is undefined`,
+	)
+	nilPointerPattern = regexp.MustCompile(`nil pointer evaluating`)
+)
+
+// Types exposed to templates, by the name Go uses for them in errors.
+var templateTypes = func() map[string]reflect.Type {
+	types := map[string]reflect.Type{}
+	for _, v := range []any{
+		Dot{},
+		dotPage{},
+		MichelInfo{},
+		config.Config{},
+		content.Content{},
+		content.Entry{},
+		content.Corpus{},
+		site.Site{},
+		site.PageMetadata{},
+		site.AssetMetadata{},
+		site.MenuEntry{},
+	} {
+		t := reflect.TypeOf(v)
+		types[t.String()] = t
+	}
+	return types
+}()
+
+// Returns a suggested fix for common template mistakes, or an empty string.
+func templateHint(
+	msg string,
+	sources map[string]templateSource,
+	funcs []string,
+) string {
+	if m := unknownFieldPattern.FindStringSubmatch(msg); m != nil {
+		t, ok := templateTypes[m[2]]
+		if !ok {
+			return ""
+		}
+
+		names := fieldsAndMethods(t)
+		if closest, ok := util.Closest(m[1], names); ok {
+			return fmt.Sprintf("Did you mean \".%s\"?", closest)
+		}
+		return fmt.Sprintf(
+			"Available fields and methods: %s.",
+			strings.Join(names, ", "),
+		)
+	}
+
+	if m := unknownFuncPattern.FindStringSubmatch(msg); m != nil {
+		if closest, ok := util.Closest(m[1], funcs); ok {
+			return fmt.Sprintf("Did you mean \"%s\"?", closest)
+		}
+		return fmt.Sprintf("Available functions: %s.", strings.Join(funcs, ", "))
+	}
+
+	if m := missingPartialPattern.FindStringSubmatch(msg); m != nil {
+		partials := []string{}
+		for name := range sources {
+			if key, ok := strings.CutPrefix(name, "partials/"); ok {
+				partials = append(partials, key)
+			}
+		}
+		slices.Sort(partials)
+
+		if closest, ok := util.Closest(m[1], partials); ok {
+			return fmt.Sprintf("Did you mean the partial \"%s\"?", closest)
+		}
+		return fmt.Sprintf(
+			"Is there a file for partial \"%s\" in the partials directory?",
+			m[1],
+		)
+	}
+
+	if nilPointerPattern.MatchString(msg) {
+		return "Something in this expression may not exist. Check for it " +
+			"with \"with\" or \"if\" first (e.g. use .Page.ContentMaybe " +
+			"inside \"with\")."
+	}
+
+	return ""
+}
+
+// Returns the sorted names of the exported fields and methods of the type.
+func fieldsAndMethods(t reflect.Type) []string {
+	names := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.IsExported() && !field.Anonymous {
+			names = append(names, field.Name)
+		}
+	}
+
+	for i := 0; i < t.NumMethod(); i++ {
+		names = append(names, t.Method(i).Name)
+	}
+
+	// Promoted fields from embedded structs
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for j := 0; j < field.Type.NumField(); j++ {
+				if f := field.Type.Field(j); f.IsExported() {
+					names = append(names, f.Name)
+				}
+			}
+		}
+	}
+
+	slices.Sort(names)
+	return slices.Compact(names)
+}
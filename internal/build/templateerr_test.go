@@ -0,0 +1,195 @@
+package build_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/build"
+	"github.com/sinclairtarget/michel/internal/testutil"
+)
+
+// Builds a site with the given files, returning the template error.
+func buildTemplateError(
+	t *testing.T,
+	files map[string]string,
+) (build.TemplateError, string) {
+	t.Helper()
+
+	tmpdir := testutil.TempFiles(t, files)
+	outdir := filepath.Join(tmpdir, "public")
+	err := build.Build(outdir, build.Opts{Source: tmpdir})
+
+	var tmplErr build.TemplateError
+	if !errors.As(err, &tmplErr) {
+		t.Fatalf("wanted template error, got %v", err)
+	}
+	return tmplErr, tmpdir
+}
+
+// Errors from Go's template package should be mapped back to the file and
+// line the template was loaded from, with a hint where we can guess one.
+func TestTemplateError(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		path   string
+		line   int
+		column int
+		hint   string
+	}{
+		{
+			name: "unknown field in page",
+			files: map[string]string{
+				"site/index.html": "---\nlayouts: []\n---\nfirst\n" +
+					"  {{ .Nwo }}\n",
+			},
+			path:   "site/index.html",
+			line:   5,
+			column: 5,
+			hint:   `Did you mean ".Now"?`,
+		},
+		{
+			name: "unknown function in partial",
+			files: map[string]string{
+				"site/index.html": "---\nlayouts: []\n---\n" +
+					"{{ partial \"p\" . }}\n",
+				"partials/p.html": "a\n  {{ partal \"q\" . }}\n",
+			},
+			path:   "partials/p.html",
+			line:   2,
+			column: -1, // Go doesn't report columns for parse errors
+			hint:   `Did you mean "partial"?`,
+		},
+		{
+			name: "missing partial",
+			files: map[string]string{
+				"site/index.html": "---\nlayouts: []\n---\n" +
+					"{{ partial \"naw\" . }}\n",
+				"partials/nav.html": "nav",
+			},
+			path:   "site/index.html",
+			line:   4,
+			column: 3,
+			hint:   `Did you mean the partial "nav"?`,
+		},
+		{
+			name: "unknown field in layout with frontmatter",
+			files: map[string]string{
+				"site/index.html": "",
+				"layouts/_default/page.html": "---\n# Comment\n---\n<p>\n" +
+					"\t{{ .Page.Layots }}\n",
+			},
+			path:   "layouts/_default/page.html",
+			line:   5,
+			column: 9,
+			hint:   `Did you mean ".Layouts"?`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmplErr, tmpdir := buildTemplateError(t, test.files)
+
+			path := filepath.Join(tmpdir, test.path)
+			if tmplErr.Path != path {
+				t.Errorf(
+					"path incorrect; wanted %s, got %s",
+					path,
+					tmplErr.Path,
+				)
+			}
+			if tmplErr.Line != test.line {
+				t.Errorf(
+					"line incorrect; wanted %d, got %d",
+					test.line,
+					tmplErr.Line,
+				)
+			}
+			if tmplErr.Column != test.column {
+				t.Errorf(
+					"column incorrect; wanted %d, got %d",
+					test.column,
+					tmplErr.Column,
+				)
+			}
+			if tmplErr.Hint != test.hint {
+				t.Errorf(
+					"hint incorrect; wanted %s, got %s",
+					test.hint,
+					tmplErr.Hint,
+				)
+			}
+
+			// Template names should be replaced by file paths
+			if strings.Contains(tmplErr.Message, `"site/`) ||
+				strings.Contains(tmplErr.Message, `"layouts/`) {
+				t.Errorf(
+					"message has template names; got %s",
+					tmplErr.Message,
+				)
+			}
+		})
+	}
+}
+
+// The snippet should show the offending line with a caret at the column,
+// keeping tabs so that the caret lines up.
+func TestTemplateErrorSnippet(t *testing.T) {
+	tmplErr, _ := buildTemplateError(t, map[string]string{
+		"site/index.html": "---\nlayouts: []\n---\n<p>\n\t{{ .Nwo }}\n</p>\n",
+	})
+
+	expected := "  3 | ---\n" +
+		"  4 | <p>\n" +
+		"  5 | \t{{ .Nwo }}\n" +
+		"    | \t   ^\n" +
+		"  6 | </p>\n" +
+		"  7 | \n"
+	if tmplErr.Snippet() != expected {
+		t.Errorf(
+			"snippet incorrect; wanted:\n%s\ngot:\n%s",
+			expected,
+			tmplErr.Snippet(),
+		)
+	}
+}
+
+// Errors we can't map should be returned unchanged.
+func TestTemplateErrorFallback(t *testing.T) {
+	sources := map[string]build.TemplateSource{
+		"site/index": {Path: "site/index.html", FirstLine: 4},
+	}
+
+	tests := []string{
+		"something unexpected",
+		`template: partials/other:2:3: executing "partials/other": boom`,
+	}
+	for _, msg := range tests {
+		original := errors.New(msg)
+		err := build.NewTemplateError(original, sources, nil)
+		if err != original {
+			t.Errorf("error for %s changed; got %v", msg, err)
+		}
+	}
+
+	// Known templates are mapped, innermost location first
+	original := errors.New(
+		`template: site/index:1:2: executing "site/index" at <partial>: ` +
+			`template: site/index:3: boom`,
+	)
+	err := build.NewTemplateError(original, sources, nil)
+
+	var tmplErr build.TemplateError
+	if !errors.As(err, &tmplErr) {
+		t.Fatalf("wanted template error, got %v", err)
+	}
+	if tmplErr.Path != "site/index.html" || tmplErr.Line != 6 ||
+		tmplErr.Column != -1 || tmplErr.Message != "boom" {
+		t.Errorf("template error incorrect; got %+v", tmplErr)
+	}
+	if !errors.Is(err, original) {
+		t.Error("template error doesn't wrap the original error")
+	}
+}
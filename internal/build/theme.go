@@ -0,0 +1,50 @@
+package build
+
+import (
+	"strings"
+
+	"github.com/sinclairtarget/michel/internal/config"
+	"github.com/sinclairtarget/michel/internal/data"
+	"github.com/sinclairtarget/michel/internal/site"
+	"github.com/sinclairtarget/michel/internal/util"
+)
+
+// The file used for a key in one of the kinds of input that themes provide.
+type LayeredFile struct {
+	Kind string // e.g. layouts
+	util.LayeredFile
+}
+
+// Returns the file used for each key of the site files, layouts, partials,
+// and data, after layering the site's own directories over its themes.
+func LayeredFiles(source string, c config.Config) ([]LayeredFile, error) {
+	dirs := dirsFor(source, c)
+
+	kinds := []struct {
+		kind   string
+		layers []string
+		key    func(string, string) string
+	}{
+		{SiteDir, dirs.SiteLayers(), site.FileKey},
+		{LayoutsDir, dirs.LayoutsLayers(), util.KeyFromPath},
+		{PartialsDir, dirs.PartialsLayers(), util.KeyFromPath},
+		{DataDir, dirs.DataLayers(), data.FileKey},
+	}
+
+	layered := []LayeredFile{}
+	for _, k := range kinds {
+		files, err := util.LayeredFiles(k.layers, k.key)
+		if err != nil {
+			return layered, err
+		}
+
+		for _, file := range files {
+			if k.kind == SiteDir {
+				file.Key = strings.TrimPrefix(file.Key, site.PageFileKeyPrefix)
+			}
+			layered = append(layered, LayeredFile{Kind: k.kind, LayeredFile: file})
+		}
+	}
+
+	return layered, nil
+}
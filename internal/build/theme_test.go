@@ -0,0 +1,54 @@
+package build_test
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/build"
+	"github.com/sinclairtarget/michel/internal/config"
+	"github.com/sinclairtarget/michel/internal/testutil"
+)
+
+// The site's own files should override its theme's, and pages should be
+// listed by their usual keys.
+func TestLayeredFiles(t *testing.T) {
+	tmpdir := testutil.TempFiles(t, map[string]string{
+		"site/index.html":            "",
+		"themes/t/site/index.html":   "",
+		"themes/t/site/about.html":   "",
+		"themes/t/site/style.css":    "",
+		"themes/t/layouts/base.html": "",
+	})
+	c := config.DefaultConfig()
+	c.Themes = []string{"themes/t"}
+
+	files, err := build.LayeredFiles(tmpdir, c)
+	if err != nil {
+		t.Fatalf("failed to list layered files: %v", err)
+	}
+
+	listed := []string{}
+	for _, file := range files {
+		rel, _ := filepath.Rel(tmpdir, file.Path)
+		listed = append(listed, file.Kind+"/"+file.Key+" "+filepath.ToSlash(rel))
+	}
+	expected := []string{
+		"site/about themes/t/site/about.html",
+		"site/index site/index.html",
+		"site/style.css themes/t/site/style.css",
+		"layouts/base themes/t/layouts/base.html",
+	}
+	if !slices.Equal(listed, expected) {
+		t.Errorf("files incorrect; wanted %v, got %v", expected, listed)
+	}
+
+	overrides := []string{filepath.Join(tmpdir, "themes", "t", "site", "index.html")}
+	if !slices.Equal(files[1].Overrides, overrides) {
+		t.Errorf(
+			"overrides incorrect; wanted %v, got %v",
+			overrides,
+			files[1].Overrides,
+		)
+	}
+}
@@ -0,0 +1,287 @@
+/*
+* Package check verifies the internal links in a built site.
+*
+* Every HTML file in the output directory is parsed. Each link (href) or
+* embedded resource (src) that points inside the site must resolve to a file
+* in the output directory. Links with a fragment must point to an element with
+* that id in the target page.
+*
+* Links to other origins are not checked.
+ */
+package check
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/sinclairtarget/michel/internal/util"
+)
+
+// A broken link found in a built page.
+type Problem struct {
+	Path   string // HTML file containing the link
+	Line   int
+	Target string // Link as written in the HTML
+	Reason string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", p.Path, p.Line, p.Target, p.Reason)
+}
+
+// Returned when broken links are found.
+type Error struct {
+	Problems []Problem
+}
+
+func (e Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "found %d broken link(s):", len(e.Problems))
+	for _, p := range e.Problems {
+		b.WriteString("\n  ")
+		b.WriteString(p.String())
+	}
+	return b.String()
+}
+
+type Opts struct {
+	BaseURL string   // Used to tell which absolute URLs point inside the site
+	Allow   []string // Glob patterns for link targets that aren't checked
+}
+
+type link struct {
+	line   int
+	target string
+}
+
+// A parsed page from the output directory.
+type page struct {
+	ids   map[string]bool
+	links []link
+}
+
+// Checks all internal links in the HTML files under the output directory.
+//
+// Returns an Error listing every broken link, or nil if there are none.
+func Check(outdir string, opts Opts) error {
+	base, err := url.Parse(opts.BaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse base URL: %w", err)
+	}
+
+	files := map[string]bool{} // slash-separated paths relative to outdir
+	pages := map[string]page{} // same keys, HTML files only
+
+	seq, finish := util.WalkFiles(outdir)
+	for filePath := range seq {
+		rel, err := filepath.Rel(outdir, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		files[rel] = true
+
+		if filepath.Ext(filePath) != ".html" {
+			continue
+		}
+
+		pages[rel], err = parsePage(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to parse \"%s\": %w", filePath, err)
+		}
+	}
+
+	err = finish()
+	if err != nil {
+		return err
+	}
+
+	c := checker{
+		base:  base,
+		files: files,
+		pages: pages,
+		allow: opts.Allow,
+	}
+
+	problems := []Problem{}
+	for _, rel := range slices.Sorted(maps.Keys(pages)) {
+		for _, l := range pages[rel].links {
+			reason := c.check(rel, l.target)
+			if reason == "" {
+				continue
+			}
+
+			problems = append(problems, Problem{
+				Path:   filepath.Join(outdir, filepath.FromSlash(rel)),
+				Line:   l.line,
+				Target: l.target,
+				Reason: reason,
+			})
+		}
+	}
+
+	if len(problems) > 0 {
+		return Error{Problems: problems}
+	}
+
+	return nil
+}
+
+type checker struct {
+	base  *url.URL
+	files map[string]bool
+	pages map[string]page
+	allow []string
+}
+
+// Returns the reason the link is broken, or an empty string if it isn't.
+func (c checker) check(from string, target string) string {
+	for _, pattern := range c.allow {
+		if util.MatchGlob(pattern, target) {
+			return ""
+		}
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "invalid URL"
+	}
+
+	if u.Scheme != "" || u.Host != "" {
+		if u.Host != c.base.Host || c.base.Host == "" {
+			return "" // external
+		}
+		if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" {
+			return ""
+		}
+	}
+
+	// Work out which file the link points to
+	var file string
+	if u.Path == "" {
+		file = from
+	} else {
+		basePath := "/" + strings.Trim(c.base.Path, "/")
+		fromURL := path.Join(basePath, from)
+
+		urlPath := u.Path
+		if !strings.HasPrefix(urlPath, "/") {
+			urlPath = path.Join(path.Dir(fromURL), urlPath)
+			if strings.HasSuffix(u.Path, "/") {
+				urlPath += "/"
+			}
+		}
+
+		prefix := strings.TrimSuffix(basePath, "/") + "/"
+		rel, ok := strings.CutPrefix(urlPath, prefix)
+		if !ok {
+			return "outside of site"
+		}
+
+		file, ok = c.resolveFile(rel)
+		if !ok {
+			return "no such file"
+		}
+	}
+
+	if u.Fragment == "" {
+		return ""
+	}
+
+	p, ok := c.pages[file]
+	if !ok {
+		return "" // Can't check fragments in non-HTML files
+	}
+	if !p.ids[u.Fragment] {
+		return "no such fragment"
+	}
+
+	return ""
+}
+
+// Finds the file in the output directory that would be served for the path.
+func (c checker) resolveFile(rel string) (string, bool) {
+	candidates := []string{rel}
+	if rel == "" || strings.HasSuffix(rel, "/") {
+		candidates = []string{rel + "index.html"}
+	} else {
+		candidates = append(candidates, rel+".html", rel+"/index.html")
+	}
+
+	for _, candidate := range candidates {
+		if c.files[candidate] {
+			return candidate, true
+		}
+	}
+
+	return "", false
+}
+
+// Attributes that contain links, by element.
+var linkAttrs = map[string]string{
+	"a":      "href",
+	"area":   "href",
+	"link":   "href",
+	"img":    "src",
+	"script": "src",
+	"iframe": "src",
+	"source": "src",
+	"video":  "src",
+	"audio":  "src",
+	"embed":  "src",
+	"track":  "src",
+}
+
+func parsePage(filePath string) (page, error) {
+	result := page{ids: map[string]bool{}}
+
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		return result, err
+	}
+
+	line := 1
+	z := html.NewTokenizer(bytes.NewReader(b))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if errors.Is(z.Err(), io.EOF) {
+				return result, nil
+			}
+			return result, z.Err()
+		}
+
+		tokenLine := line
+		line += bytes.Count(z.Raw(), []byte("\n"))
+
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+
+		token := z.Token()
+		for _, attr := range token.Attr {
+			switch {
+			case attr.Key == "id":
+				result.ids[attr.Val] = true
+			case attr.Key == "name" && token.Data == "a":
+				result.ids[attr.Val] = true
+			case attr.Key == linkAttrs[token.Data]:
+				result.links = append(result.links, link{
+					line:   tokenLine,
+					target: attr.Val,
+				})
+			}
+		}
+	}
+}
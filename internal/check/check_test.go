@@ -0,0 +1,91 @@
+package check_test
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/check"
+	"github.com/sinclairtarget/michel/internal/testutil"
+)
+
+func TestCheck(t *testing.T) {
+	tmpdir := t.TempDir()
+	testutil.WriteFiles(t, tmpdir, map[string]string{
+		"index.html": `<html>
+<body>
+<a href="/blog/post.html#intro">Post</a>
+<a href="blog/">Blog</a>
+<a href="/missing.html">Missing</a>
+<img src="img/logo.png">
+<a href="https://example.com/elsewhere.html">Elsewhere</a>
+<a href="blog/post.html#nope">Bad fragment</a>
+<a href="/drafts/foo.html">Allowed</a>
+</body>
+</html>
+`,
+		"blog/index.html": `<a href="../index.html">Home</a>`,
+		"blog/post.html":  `<h2 id="intro">Intro</h2><a href="#intro">Self</a>`,
+		"img/logo.png":    "",
+	})
+
+	err := check.Check(tmpdir, check.Opts{
+		BaseURL: "https://foo.com",
+		Allow:   []string{"/drafts/*"},
+	})
+
+	var checkErr check.Error
+	if !errors.As(err, &checkErr) {
+		t.Fatalf("expected check.Error but got: %v", err)
+	}
+
+	got := []string{}
+	for _, problem := range checkErr.Problems {
+		if problem.Path != filepath.Join(tmpdir, "index.html") {
+			t.Errorf("problem in unexpected file \"%s\"", problem.Path)
+		}
+		got = append(got, problem.Target)
+	}
+
+	want := []string{"/missing.html", "blog/post.html#nope"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if checkErr.Problems[0].Line != 5 {
+		t.Errorf(
+			"line incorrect; wanted 5, got %d",
+			checkErr.Problems[0].Line,
+		)
+	}
+}
+
+// Links should be checked relative to the path in the base URL.
+func TestCheckBaseURLPath(t *testing.T) {
+	tmpdir := t.TempDir()
+	testutil.WriteFiles(t, tmpdir, map[string]string{
+		"index.html": `<a href="/bar/about.html">About</a>
+<a href="https://foo.com/bar/about.html">About</a>
+<a href="/about.html">Outside</a>`,
+		"about.html": "",
+	})
+
+	err := check.Check(tmpdir, check.Opts{BaseURL: "https://foo.com/bar/"})
+
+	var checkErr check.Error
+	if !errors.As(err, &checkErr) {
+		t.Fatalf("expected check.Error but got: %v", err)
+	}
+
+	if len(checkErr.Problems) != 1 {
+		t.Fatalf("expected 1 problem but got %d", len(checkErr.Problems))
+	}
+
+	if checkErr.Problems[0].Target != "/about.html" {
+		t.Errorf(
+			"wrong target for problem; wanted \"/about.html\", got \"%s\"",
+			checkErr.Problems[0].Target,
+		)
+	}
+}
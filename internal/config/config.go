@@ -1,13 +1,24 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"log/slog"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/sinclairtarget/michel/internal/merrors"
+	"github.com/sinclairtarget/michel/internal/util"
 )
 
 // Fixed filename for config file
@@ -17,7 +28,40 @@ const Filename string = "michel.yaml"
 type Config struct {
 	Title       string
 	Description string
-	BaseURL     string `yaml:"baseURL"`
+	BaseURL     string                 `yaml:"baseURL"`
+	Highlight   HighlightConfig        `yaml:",omitempty"`
+	Math        MathConfig             `yaml:",omitempty"`
+	Check       CheckConfig            `yaml:",omitempty"`
+	Search      SearchConfig           `yaml:",omitempty"`
+	Related     RelatedConfig          `yaml:",omitempty"`
+	Languages   []Language             `yaml:",omitempty"` // First is the default
+	Menus       map[string][]MenuEntry `yaml:",omitempty"`
+	Params      map[string]any         `yaml:",omitempty"` // Free-form
+	Environment string                 `yaml:",omitempty"` // e.g. production
+	Dirs        DirsConfig             `yaml:",omitempty"`
+	Themes      []string               `yaml:",omitempty"` // First wins
+}
+
+// Names of the input directories, relative to the site root.
+//
+// Any left empty use the default name.
+type DirsConfig struct {
+	Content    string `yaml:",omitempty"`
+	Site       string `yaml:",omitempty"`
+	Layouts    string `yaml:",omitempty"`
+	Partials   string `yaml:",omitempty"`
+	I18n       string `yaml:"i18n,omitempty"`
+	Data       string `yaml:",omitempty"`
+	Archetypes string `yaml:",omitempty"`
+}
+
+// Configuration for build-time syntax highlighting of code blocks.
+//
+// Highlighting is off unless enabled, so that turning it on is a choice rather
+// than a change to every existing site's output.
+type HighlightConfig struct {
+	Enabled     bool `yaml:",omitempty"`
+	LineNumbers bool `yaml:"lineNumbers,omitempty"` // Number every code block
 }
 
 // Returns the default config.
@@ -36,14 +80,139 @@ func (c Config) Dump() string {
 	return string(d)
 }
 
+// Configuration for build-time rendering of math.
+type MathConfig struct {
+	Render bool `yaml:",omitempty"` // Render math to MathML
+}
+
+// Configuration for checking internal links in the built site.
+type CheckConfig struct {
+	Allow []string `yaml:",omitempty"` // Glob patterns for links to skip
+}
+
+// Configuration for generating a search index.
+type SearchConfig struct {
+	Enabled  bool     `yaml:",omitempty"`
+	Include  []string `yaml:",omitempty"` // Glob patterns for content keys
+	Exclude  []string `yaml:",omitempty"` // Glob patterns for content keys
+	Output   string   `yaml:",omitempty"` // Path of index in output directory
+	Inverted bool     `yaml:",omitempty"` // Include a stemmed inverted index
+}
+
+// Weights used to score related content.
+//
+// Any weights left unset are zero, so that a signal can be turned off. If no
+// weights are set at all, the defaults are used.
+type RelatedConfig struct {
+	Tags    float64 `yaml:",omitempty"` // Weight of shared tags
+	Section float64 `yaml:",omitempty"` // Weight of shared key prefix
+	Text    float64 `yaml:",omitempty"` // Weight of text similarity
+}
+
+// A language the site is published in.
+type Language struct {
+	Code   string // e.g. "de"
+	Name   string `yaml:",omitempty"` // e.g. "Deutsch"
+	Prefix string `yaml:",omitempty"` // URL path prefix; "/" for none
+}
+
+// Returns the URL path prefix for pages in the language.
+//
+// The prefix defaults to the language code.
+func (l Language) URLPrefix() string {
+	if l.Prefix == "" {
+		return l.Code
+	}
+
+	return strings.Trim(l.Prefix, "/")
+}
+
+// Returns the codes of the configured languages.
+func (c Config) LanguageCodes() []string {
+	codes := []string{}
+	for _, lang := range c.Languages {
+		codes = append(codes, lang.Code)
+	}
+	return codes
+}
+
+// An entry in a navigation menu.
+//
+// Entries can also be declared in page frontmatter, in which case Page is
+// implied.
+type MenuEntry struct {
+	Identifier string `yaml:",omitempty"` // Defaults to Page, then Name
+	Name       string `yaml:",omitempty"`
+	Page       string `yaml:",omitempty"`    // Key of the page to link to
+	URL        string `yaml:"url,omitempty"` // Used if there is no Page
+	Parent     string `yaml:",omitempty"`    // Identifier of parent entry
+	Weight     int    `yaml:",omitempty"`    // Lighter entries come first
+}
+
+// Options for loading the config.
+type Opts struct {
+	Path        string       // Path to config file; defaults to Filename
+	Environment string       // e.g. "production"; selects a config overlay
+	Logger      *slog.Logger // For warnings; defaults to slog.Default()
+}
+
 // Loads the config from disk.
 //
 // We first instantiate the default config, then update it with any non-empty
-// fields loaded from disk.
-func Load() (Config, error) {
+// fields loaded from disk. If an environment is given (or set by the
+// MICHEL_ENVIRONMENT environment variable, or by the environment key in the
+// config file), the overlay file for that environment is merged on top.
+// Finally, any MICHEL_* environment variables override individual keys.
+func Load(opts Opts) (Config, error) {
+	path := opts.Path
+	if path == "" {
+		path = Filename
+	}
+
+	c, err := LoadFile(path)
+	if err != nil {
+		return c, err
+	}
+
+	env := opts.Environment
+	if env == "" {
+		env = os.Getenv(envPrefix + "ENVIRONMENT")
+	}
+	if env == "" {
+		env = c.Environment
+	}
+
+	if env != "" {
+		c.Environment = env
+		err = overlayFile(&c, EnvFilename(path, env))
+		if err != nil {
+			return c, err
+		}
+	}
+
+	err = overlayEnv(&c, os.Environ(), util.LoggerOrDefault(opts.Logger))
+	if err != nil {
+		return c, err
+	}
+
+	return c, validate(path, c)
+}
+
+// Returns the name of the overlay file for the environment.
+//
+// e.g. michel.yaml production -> michel.production.yaml
+func EnvFilename(path string, env string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + env + ext
+}
+
+// Loads the config from the file at the given path.
+//
+// If there is no file, returns the default config.
+func LoadFile(path string) (Config, error) {
 	c := DefaultConfig()
 
-	f, err := os.Open(Filename)
+	f, err := os.Open(path)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
 			return c, nil
@@ -58,8 +227,7 @@ func Load() (Config, error) {
 		return c, err
 	}
 
-	loaded := Config{}
-	err = yaml.Unmarshal(d, &loaded)
+	loaded, err := decode(path, d)
 	if err != nil {
 		return c, err
 	}
@@ -73,6 +241,173 @@ func Load() (Config, error) {
 	if loaded.BaseURL != "" {
 		c.BaseURL = loaded.BaseURL
 	}
+	if loaded.Highlight != (HighlightConfig{}) {
+		c.Highlight = loaded.Highlight
+	}
+	if loaded.Math != (MathConfig{}) {
+		c.Math = loaded.Math
+	}
+	if loaded.Check.Allow != nil {
+		c.Check = loaded.Check
+	}
+	if loaded.Search.Enabled {
+		c.Search = loaded.Search
+	}
+	if loaded.Related != (RelatedConfig{}) {
+		c.Related = loaded.Related
+	}
+	if loaded.Languages != nil {
+		c.Languages = loaded.Languages
+	}
+	if loaded.Menus != nil {
+		c.Menus = loaded.Menus
+	}
+	if loaded.Params != nil {
+		c.Params = loaded.Params
+	}
+	if loaded.Environment != "" {
+		c.Environment = loaded.Environment
+	}
+	if loaded.Dirs != (DirsConfig{}) {
+		c.Dirs = loaded.Dirs
+	}
+	if loaded.Themes != nil {
+		c.Themes = loaded.Themes
+	}
+
+	return c, validate(path, c)
+}
+
+// Merges the overlay file at the given path over the config.
+//
+// Keys in the overlay replace the same keys in the config; everything else is
+// left alone. It's fine for the overlay not to exist.
+func overlayFile(c *Config, path string) error {
+	d, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			slog.Debug("no config overlay for environment", "path", path)
+			return nil
+		}
+
+		return err
+	}
+
+	return decodeInto(path, d, c)
+}
+
+// Returns an error if the config loaded from the path has invalid values.
+func validate(path string, c Config) error {
+	if c.BaseURL != "" {
+		u, err := url.Parse(c.BaseURL)
+
+		reason := ""
+		switch {
+		case err != nil:
+			reason = "not a valid URL"
+		case u.Scheme != "http" && u.Scheme != "https":
+			reason = "scheme must be http or https"
+		case u.Host == "":
+			reason = "URL has no host"
+		}
+
+		if reason != "" {
+			return merrors.InvalidConfigValueError{
+				Path:   path,
+				Key:    "baseURL",
+				Value:  c.BaseURL,
+				Reason: reason,
+				Hint: "The base URL should be the full URL of the site, " +
+					"e.g. https://example.com/blog/.",
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for i, lang := range c.Languages {
+		if lang.Code == "" {
+			return merrors.InvalidConfigValueError{
+				Path:   path,
+				Key:    fmt.Sprintf("languages[%d].code", i),
+				Reason: "language has no code",
+				Hint:   "Give every language a code, e.g. \"code: en\".",
+			}
+		}
+		if seen[lang.Code] {
+			return merrors.InvalidConfigValueError{
+				Path:   path,
+				Key:    fmt.Sprintf("languages[%d].code", i),
+				Value:  lang.Code,
+				Reason: "language declared twice",
+				Hint:   "Remove the duplicate language.",
+			}
+		}
+		seen[lang.Code] = true
+	}
+
+	if output := c.Search.Output; output != "" {
+		parts := strings.Split(filepath.ToSlash(output), "/")
+		if filepath.IsAbs(output) ||
+			strings.HasPrefix(output, "/") ||
+			slices.Contains(parts, "..") {
+			return merrors.InvalidConfigValueError{
+				Path:   path,
+				Key:    "search.output",
+				Value:  output,
+				Reason: "path must be inside the output directory",
+				Hint: "Give a path relative to the output directory, " +
+					"e.g. \"search/index.json\".",
+			}
+		}
+	}
+
+	return nil
+}
+
+var unknownFieldPattern = regexp.MustCompile(
+	`line (\d+): field (\S+) not found in type (\S+)`,
+)
+
+// Decodes the YAML config, rejecting keys that don't match any config field.
+func decode(path string, d []byte) (Config, error) {
+	loaded := Config{}
+	err := decodeInto(path, d, &loaded)
+	return loaded, err
+}
+
+// Decodes the YAML config over the given config.
+//
+// Keys that don't match any config field are rejected.
+func decodeInto(path string, d []byte, c *Config) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(d))
+	decoder.KnownFields(true)
+	err := decoder.Decode(c)
+	if errors.Is(err, io.EOF) {
+		return nil // Empty file
+	}
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		for _, msg := range typeErr.Errors {
+			match := unknownFieldPattern.FindStringSubmatch(msg)
+			if match == nil {
+				continue
+			}
+
+			line, _ := strconv.Atoi(match[1])
+			didYouMean, _ := util.Closest(match[2], knownKeys(match[3]))
+			return merrors.UnknownConfigKeyError{
+				Path:       path,
+				Line:       line,
+				Key:        match[2],
+				DidYouMean: didYouMean,
+			}
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to parse \"%s\": %w", path, err)
+	}
 
-	return c, nil
+	return nil
 }
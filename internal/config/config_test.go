@@ -1,14 +1,21 @@
 package config_test
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"testing"
 
 	"github.com/sinclairtarget/michel/internal/config"
+	"github.com/sinclairtarget/michel/internal/merrors"
+	"github.com/sinclairtarget/michel/internal/testutil"
 )
 
 func TestDefault(t *testing.T) {
 	defaults := config.DefaultConfig()
-	c, err := config.Load()
+	c, err := config.Load(config.Opts{})
 	if err != nil {
 		t.Fatalf("error loading config: %v", err)
 	}
@@ -37,3 +44,335 @@ func TestDump(t *testing.T) {
 		t.Error("dumped config was empty")
 	}
 }
+
+// Free-form params should be preserved.
+func TestLoadParams(t *testing.T) {
+	path := writeConfig(t, `title: My Site
+params:
+  author: Ada
+  social:
+    mastodon: "@ada"
+`)
+
+	c, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatalf("error loading config: %v", err)
+	}
+
+	if c.Params["author"] != "Ada" {
+		t.Errorf(
+			"author param incorrect; wanted \"Ada\", got %v",
+			c.Params["author"],
+		)
+	}
+
+	social, ok := c.Params["social"].(map[string]any)
+	if !ok || social["mastodon"] != "@ada" {
+		t.Errorf(
+			"social param incorrect; wanted map with mastodon, got %v",
+			c.Params["social"],
+		)
+	}
+}
+
+// Unknown keys should be rejected, reporting the key and line.
+func TestLoadUnknownKey(t *testing.T) {
+	path := writeConfig(t, `title: My Site
+author: Ada
+`)
+
+	_, err := config.LoadFile(path)
+
+	var keyErr merrors.UnknownConfigKeyError
+	if !errors.As(err, &keyErr) {
+		t.Fatalf("wanted unknown key error, got %v", err)
+	}
+
+	if keyErr.Key != "author" || keyErr.Line != 2 {
+		t.Errorf(
+			"unknown key error incorrect; wanted \"author\" at line 2, got "+
+				"\"%s\" at line %d",
+			keyErr.Key,
+			keyErr.Line,
+		)
+	}
+}
+
+func writeConfig(t *testing.T, text string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), config.Filename)
+	err := os.WriteFile(path, []byte(text), 0o644)
+	if err != nil {
+		t.Fatalf("failed to write config to tmp dir: %v", err)
+	}
+
+	return path
+}
+
+// The overlay for the environment should be merged over the config, then
+// MICHEL_* environment variables should override individual keys.
+func TestLoadEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	files := map[string]string{
+		config.Filename: `title: My Site
+baseURL: https://staging.example.com
+params:
+  author: Ada
+`,
+		"michel.production.yaml": `baseURL: https://example.com
+params:
+  analytics: true
+`,
+	}
+	for name, text := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644)
+		if err != nil {
+			t.Fatalf("failed to write config to tmp dir: %v", err)
+		}
+	}
+
+	t.Setenv("MICHEL_TITLE", "Overridden")
+	t.Setenv("MICHEL_HIGHLIGHT_LINE_NUMBERS", "true")
+	t.Setenv("MICHEL_PARAMS_ANALYTICS_ID", "UA-1")
+
+	c, err := config.Load(config.Opts{Environment: "production"})
+	if err != nil {
+		t.Fatalf("error loading config: %v", err)
+	}
+
+	if c.Environment != "production" {
+		t.Errorf(
+			"environment incorrect; wanted \"production\", got \"%s\"",
+			c.Environment,
+		)
+	}
+	if c.BaseURL != "https://example.com" {
+		t.Errorf(
+			"base URL incorrect; wanted \"https://example.com\", got \"%s\"",
+			c.BaseURL,
+		)
+	}
+	if c.Title != "Overridden" {
+		t.Errorf("title incorrect; wanted \"Overridden\", got \"%s\"", c.Title)
+	}
+	if !c.Highlight.LineNumbers {
+		t.Error("line numbers not enabled by environment variable")
+	}
+
+	expected := map[string]any{
+		"author":       "Ada",
+		"analytics":    true,
+		"analytics_id": "UA-1",
+	}
+	for key, value := range expected {
+		if c.Params[key] != value {
+			t.Errorf(
+				"param \"%s\" incorrect; wanted %v, got %v",
+				key,
+				value,
+				c.Params[key],
+			)
+		}
+	}
+}
+
+// Environment variables that don't match a config key should be ignored, since
+// other tools use MICHEL_* names too.
+func TestLoadUnknownEnvVar(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("MICHEL_HOME", "/x")
+	t.Setenv("MICHEL_TITLE", "Overridden")
+
+	c, err := config.Load(config.Opts{})
+	if err != nil {
+		t.Fatalf("error loading config: %v", err)
+	}
+
+	if c.Title != "Overridden" {
+		t.Errorf("title incorrect; wanted \"Overridden\", got \"%s\"", c.Title)
+	}
+}
+
+// Environment variables for string keys should be used as is, even if they
+// look like YAML.
+func TestLoadEnvStrings(t *testing.T) {
+	values := []string{"My: Site", "# Heading", "- item", "[a, b]", ""}
+
+	for _, value := range values {
+		t.Run(value, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			t.Setenv("MICHEL_TITLE", value)
+
+			c, err := config.Load(config.Opts{})
+			if err != nil {
+				t.Fatalf("error loading config: %v", err)
+			}
+
+			if c.Title != value {
+				t.Errorf(
+					"title incorrect; wanted \"%s\", got \"%s\"",
+					value,
+					c.Title,
+				)
+			}
+		})
+	}
+}
+
+// Environment variables for other keys, and for params, should be parsed as
+// YAML.
+func TestLoadEnvYAML(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("MICHEL_HIGHLIGHT_ENABLED", "true")
+	t.Setenv("MICHEL_CHECK_ALLOW", "[/a/*, /b/*]")
+	t.Setenv("MICHEL_RELATED_TAGS", "")
+	t.Setenv("MICHEL_PARAMS_COUNT", "3")
+	t.Setenv("MICHEL_PARAMS_GREETING", "Hello: world")
+
+	c, err := config.Load(config.Opts{})
+	if err != nil {
+		t.Fatalf("error loading config: %v", err)
+	}
+
+	if !c.Highlight.Enabled {
+		t.Error("highlighting not enabled by environment variable")
+	}
+	if !slices.Equal(c.Check.Allow, []string{"/a/*", "/b/*"}) {
+		t.Errorf(
+			"check.allow incorrect; wanted [/a/* /b/*], got %v",
+			c.Check.Allow,
+		)
+	}
+	if c.Related.Tags != 0 {
+		t.Errorf("related.tags incorrect; wanted 0, got %v", c.Related.Tags)
+	}
+	if c.Params["count"] != 3 {
+		t.Errorf("params.count incorrect; wanted 3, got %v", c.Params["count"])
+	}
+
+	greeting := map[string]any{"Hello": "world"}
+	if !reflect.DeepEqual(c.Params["greeting"], greeting) {
+		t.Errorf(
+			"params.greeting incorrect; wanted %v, got %v",
+			greeting,
+			c.Params["greeting"],
+		)
+	}
+}
+
+// The environment can also be set in the config file itself.
+func TestLoadEnvironmentFromFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	testutil.WriteFiles(t, dir, map[string]string{
+		config.Filename:          "environment: production\n",
+		"michel.production.yaml": "baseURL: https://example.com\n",
+	})
+
+	c, err := config.Load(config.Opts{})
+	if err != nil {
+		t.Fatalf("error loading config: %v", err)
+	}
+
+	if c.Environment != "production" || c.BaseURL != "https://example.com" {
+		t.Errorf(
+			"production overlay not applied; got environment \"%s\", "+
+				"base URL \"%s\"",
+			c.Environment,
+			c.BaseURL,
+		)
+	}
+}
+
+// The config can be loaded from any path, and can rename input directories.
+func TestLoadPathAndDirs(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	path := writeConfig(t, `dirs:
+  content: posts
+  i18n: translations
+`)
+
+	c, err := config.Load(config.Opts{Path: path})
+	if err != nil {
+		t.Fatalf("error loading config: %v", err)
+	}
+
+	if c.Dirs.Content != "posts" || c.Dirs.I18n != "translations" {
+		t.Errorf(
+			"dirs incorrect; wanted posts and translations, got %+v",
+			c.Dirs,
+		)
+	}
+	if c.Dirs.Site != "" {
+		t.Errorf("site dir incorrect; wanted empty, got \"%s\"", c.Dirs.Site)
+	}
+}
+
+// Unknown keys that look like typos of known keys should get a suggestion,
+// including in nested sections.
+func TestLoadDidYouMean(t *testing.T) {
+	tests := map[string]string{
+		"baseUrl: https://example.com\n":   "baseURL",
+		"highlight:\n  linenumber: true\n": "lineNumbers",
+	}
+
+	for text, expected := range tests {
+		_, err := config.LoadFile(writeConfig(t, text))
+
+		var keyErr merrors.UnknownConfigKeyError
+		if !errors.As(err, &keyErr) {
+			t.Errorf("wanted unknown key error, got %v", err)
+			continue
+		}
+
+		if keyErr.DidYouMean != expected {
+			t.Errorf(
+				"suggestion incorrect; wanted \"%s\", got \"%s\"",
+				expected,
+				keyErr.DidYouMean,
+			)
+		}
+	}
+}
+
+// The search index must be written inside the output directory.
+func TestLoadInvalidSearchOutput(t *testing.T) {
+	invalid := []string{"../../x.json", "/tmp/x.json", "search/../../x.json"}
+	for _, output := range invalid {
+		text := "search:\n  enabled: true\n  output: " + output + "\n"
+		path := writeConfig(t, text)
+
+		_, err := config.LoadFile(path)
+
+		var valueErr merrors.InvalidConfigValueError
+		if !errors.As(err, &valueErr) || valueErr.Key != "search.output" {
+			t.Errorf("wanted invalid value error for %s, got %v", output, err)
+		}
+	}
+
+	text := "search:\n  enabled: true\n  output: search/index.json\n"
+	path := writeConfig(t, text)
+	_, err := config.LoadFile(path)
+	if err != nil {
+		t.Errorf("failed to load config with valid search output: %v", err)
+	}
+}
+
+// The base URL must be an absolute http(s) URL.
+func TestLoadInvalidBaseURL(t *testing.T) {
+	invalid := []string{"example.com", "ftp://example.com", "https://"}
+	for _, baseURL := range invalid {
+		path := writeConfig(t, "baseURL: "+baseURL+"\n")
+
+		_, err := config.LoadFile(path)
+
+		var valueErr merrors.InvalidConfigValueError
+		if !errors.As(err, &valueErr) {
+			t.Errorf("wanted invalid value error for %s, got %v", baseURL, err)
+		}
+	}
+}
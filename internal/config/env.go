@@ -0,0 +1,189 @@
+package config
+
+import (
+	"iter"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Prefix for environment variables that override config keys.
+const envPrefix = "MICHEL_"
+
+// Overrides config keys with the values of MICHEL_* environment variables.
+//
+// The rest of the variable name is matched against config keys, ignoring case,
+// with underscores separating nested keys. Underscores within a key can be
+// left in or out, so MICHEL_HIGHLIGHT_LINENUMBERS and
+// MICHEL_HIGHLIGHT_LINE_NUMBERS both set highlight.lineNumbers. Under params,
+// the rest of the name is used as a single lowercase key, so
+// MICHEL_PARAMS_ANALYTICS_ID sets params.analytics_id.
+//
+// Values for string keys are used as is. Other values, and values under
+// params, are parsed as YAML, so "true" is a boolean and "[a, b]" is a list.
+// Variables that don't name a config key are ignored with a warning.
+func overlayEnv(c *Config, environ []string, logger *slog.Logger) error {
+	overlay := map[string]any{}
+
+	slices.Sort(environ)
+	for _, pair := range environ {
+		name, value, _ := strings.Cut(pair, "=")
+		rest, ok := strings.CutPrefix(name, envPrefix)
+		if !ok || rest == "ENVIRONMENT" {
+			continue
+		}
+
+		// Other tools use MICHEL_* variables too, so a variable that doesn't
+		// name a config key is not an error.
+		segments := strings.Split(rest, "_")
+		if !setEnvKey(overlay, reflect.TypeOf(*c), segments, value) {
+			logger.Warn(
+				"ignoring environment variable that names no config key",
+				"variable",
+				name,
+			)
+		}
+	}
+
+	if len(overlay) == 0 {
+		return nil
+	}
+
+	d, err := yaml.Marshal(overlay)
+	if err != nil {
+		return err
+	}
+
+	return decodeInto("environment", d, c)
+}
+
+// Sets the value in the overlay at the config key named by the segments.
+//
+// Returns false if the segments don't name a key in the struct type.
+func setEnvKey(
+	overlay map[string]any,
+	t reflect.Type,
+	segments []string,
+	value string,
+) bool {
+	// Prefer the longest run of segments that matches a field
+	for n := len(segments); n >= 1; n-- {
+		name := strings.ToLower(strings.Join(segments[:n], ""))
+		rest := segments[n:]
+
+		for field := range fields(t) {
+			key := yamlKey(field)
+			if strings.ToLower(key) != name {
+				continue
+			}
+
+			switch field.Type.Kind() {
+			case reflect.Struct:
+				child, _ := overlay[key].(map[string]any)
+				if child == nil {
+					child = map[string]any{}
+				}
+				if setEnvKey(child, field.Type, rest, value) {
+					overlay[key] = child
+					return true
+				}
+			case reflect.Map:
+				if len(rest) == 0 {
+					continue
+				}
+
+				child, _ := overlay[key].(map[string]any)
+				if child == nil {
+					child = map[string]any{}
+				}
+				child[strings.ToLower(strings.Join(rest, "_"))] = parseEnvValue(
+					value,
+				)
+				overlay[key] = child
+				return true
+			default:
+				if len(rest) == 0 {
+					if field.Type.Kind() == reflect.String {
+						overlay[key] = value
+					} else {
+						overlay[key] = parseEnvValue(value)
+					}
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// Returns the environment variable value parsed as YAML, or the value itself
+// if it isn't valid YAML.
+func parseEnvValue(value string) any {
+	var parsed any
+	err := yaml.Unmarshal([]byte(value), &parsed)
+	if err != nil {
+		return value
+	}
+	return parsed
+}
+
+// Returns an iterator over the exported fields of the struct type.
+func fields(t reflect.Type) iter.Seq[reflect.StructField] {
+	return func(yield func(reflect.StructField) bool) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			if !yield(field) {
+				return
+			}
+		}
+	}
+}
+
+// Returns the YAML key for the struct field.
+func yamlKey(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// Returns the YAML keys of the config struct type with the given name (e.g.
+// "config.HighlightConfig"), for suggesting corrections to unknown keys.
+func knownKeys(typeName string) []string {
+	var find func(t reflect.Type) []string
+	find = func(t reflect.Type) []string {
+		switch t.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Pointer:
+			return find(t.Elem())
+		case reflect.Struct:
+		default:
+			return nil
+		}
+
+		if t.String() == typeName {
+			keys := []string{}
+			for field := range fields(t) {
+				keys = append(keys, yamlKey(field))
+			}
+			return keys
+		}
+
+		for field := range fields(t) {
+			if keys := find(field.Type); keys != nil {
+				return keys
+			}
+		}
+		return nil
+	}
+
+	return find(reflect.TypeOf(Config{}))
+}
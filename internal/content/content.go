@@ -18,6 +18,8 @@ type frontmatter struct {
 	Title       string
 	Description string
 	Date        string
+	Tags        []string
+	Draft       bool
 }
 
 // If Date is missing, fallback to Jan 1, year 1.
@@ -50,6 +52,9 @@ type Metadata struct {
 	Title       string
 	Description string
 	Date        time.Time
+	Tags        []string
+	Draft       bool   // Drafts are left out of generated indexes
+	Language    string // Language code; empty if no languages configured
 }
 
 func (m Metadata) Key() string { return m.key }
@@ -81,6 +86,30 @@ type Content struct {
 	Root *myst.Node
 }
 
+// Average adult reading speed, used to estimate reading time.
+const wordsPerMinute = 200
+
+// Returns the number of words in the content, excluding code blocks and
+// comments.
+func (c Content) WordCount() (int, error) {
+	text, err := c.Root.PlainText()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(strings.Fields(text)), nil
+}
+
+// Returns the estimated time to read the content in whole minutes, rounded up.
+func (c Content) ReadingTime() (int, error) {
+	count, err := c.WordCount()
+	if err != nil {
+		return 0, err
+	}
+
+	return (count + wordsPerMinute - 1) / wordsPerMinute, nil
+}
+
 // Loads content partially into memory, reading only the YAML frontmatter.
 func LoadMetadata(contentDir string, path string) (Metadata, error) {
 	slog.Debug("loading content from disk (metadata only)", "path", path)
@@ -104,6 +133,8 @@ func LoadMetadata(contentDir string, path string) (Metadata, error) {
 	// Load frontmatter fields
 	metadata.Title = result.Frontmatter.Title
 	metadata.Description = result.Frontmatter.Description
+	metadata.Tags = result.Frontmatter.Tags
+	metadata.Draft = result.Frontmatter.Draft
 
 	metadata.Date, err = result.Frontmatter.ParsedDate()
 	if err != nil {
@@ -121,15 +152,29 @@ func LoadMetadata(contentDir string, path string) (Metadata, error) {
 func LoadContent(m Metadata) (Content, error) {
 	slog.Debug("loading content from disk", "path", m.Filepath)
 
-	content := Content{Metadata: m}
+	text, err := readText(m.Filepath)
+	if err != nil {
+		return Content{Metadata: m}, err
+	}
+
+	return parseContent(m, text)
+}
 
-	result, err := load.ReadFile[frontmatter](m.Filepath, load.Opts{})
+// Returns the text of the content file after the frontmatter.
+func readText(path string) (string, error) {
+	result, err := load.ReadFile[frontmatter](path, load.Opts{})
 	if err != nil {
-		return content, err
+		return "", err
 	}
 
-	// Parse MyST
-	content.Root, err = myst.Parse(result.Text)
+	return result.Text, nil
+}
+
+func parseContent(m Metadata, text string) (Content, error) {
+	content := Content{Metadata: m}
+
+	var err error
+	content.Root, err = myst.ParseFile(text, m.Filepath)
 	if err != nil {
 		return content, fmt.Errorf(
 			"failed to parse content file \"%s\": %w",
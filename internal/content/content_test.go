@@ -3,6 +3,7 @@ package content_test
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
@@ -147,3 +148,83 @@ Here is the second paragraph.
 		t.Errorf("default year should have been 1; got %d", m.Date.Year())
 	}
 }
+
+func TestWordCount(t *testing.T) {
+	const fileContents = `---
+title: My Blog Post
+---
+This is a blog post. Here is the *first* paragraph.
+
+% This is a comment.
+
+` + "```python\nprint('hello world')\n```" + `
+
+## Subheading
+Here is the second paragraph.
+`
+	tmpdir := t.TempDir()
+	filename := filepath.Join(tmpdir, "test-content.md")
+	err := os.WriteFile(filename, []byte(fileContents), 0o644)
+	if err != nil {
+		t.Fatalf("failed to write content file to tmp dir: %v", err)
+	}
+
+	m, err := content.LoadMetadata(tmpdir, filename)
+	if err != nil {
+		t.Fatalf("failed to load content: %v", err)
+	}
+
+	c, err := content.LoadContent(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := 16
+	count, err := c.WordCount()
+	if err != nil {
+		t.Fatalf("failed to count words: %v", err)
+	}
+	if count != expected {
+		t.Errorf("word count incorrect; wanted %d, got %d", expected, count)
+	}
+
+	minutes, err := c.ReadingTime()
+	if err != nil {
+		t.Fatalf("failed to compute reading time: %v", err)
+	}
+	if minutes != 1 {
+		t.Errorf("reading time incorrect; wanted 1, got %d", minutes)
+	}
+}
+
+func TestLoadTagsAndDraft(t *testing.T) {
+	const fileContents = `---
+title: My Blog Post
+tags:
+  - go
+  - myst
+draft: true
+---
+This is a blog post.
+`
+	tmpdir := t.TempDir()
+	filename := filepath.Join(tmpdir, "test-content.md")
+	err := os.WriteFile(filename, []byte(fileContents), 0o644)
+	if err != nil {
+		t.Fatalf("failed to write content file to tmp dir: %v", err)
+	}
+
+	m, err := content.LoadMetadata(tmpdir, filename)
+	if err != nil {
+		t.Fatalf("failed to load content: %v", err)
+	}
+
+	expectedTags := []string{"go", "myst"}
+	if !slices.Equal(m.Tags, expectedTags) {
+		t.Errorf("tags incorrect; wanted %v, got %v", expectedTags, m.Tags)
+	}
+
+	if !m.Draft {
+		t.Error("content should have been marked as draft")
+	}
+}
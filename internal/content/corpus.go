@@ -2,6 +2,7 @@ package content
 
 import (
 	"errors"
+	"fmt"
 	"iter"
 	"log/slog"
 	"maps"
@@ -18,7 +19,7 @@ type Entry struct {
 }
 
 func (e Entry) Root() (*myst.Node, error) {
-	content, err := e.corpus.Get(e.Key())
+	content, err := e.corpus.load(e.Metadata)
 	if err != nil {
 		return nil, err
 	}
@@ -26,24 +27,61 @@ func (e Entry) Root() (*myst.Node, error) {
 	return content.Root, nil
 }
 
+// Returns the translations of this content into the other languages, in the
+// order the languages are configured.
+func (e Entry) Translations() []Entry {
+	translations := []Entry{}
+	for _, lang := range e.corpus.languages {
+		if lang == e.Language {
+			continue
+		}
+
+		if t, ok := e.corpus.byLanguage[lang][e.Key()]; ok {
+			translations = append(translations, t)
+		}
+	}
+
+	return translations
+}
+
 // Collection of content loaded from disk.
 //
 // Metadata is kept in memory for every content file. The parsed MyST ASTs are
 // loaded lazily.
 //
+// If the site has more than one language, a corpus only gives access to the
+// content in one language at a time. Translations of the same content share a
+// key.
+//
 // TODO: Cache the parsed MyST nodes so we don't have to re-read files, if this
 // reveals itself to be more performant.
 type Corpus struct {
-	entries map[string]Entry
-	used    map[string]bool // content that has been fully loaded via Get()
+	entries    map[string]Entry // content in the current language
+	language   string
+	languages  []string
+	byLanguage map[string]map[string]Entry
+	used       map[string]bool // paths of content fully loaded via Get()
+	related    *related
 }
 
 // Loads all content metadata into memory.
-func LoadCorpus(dir string) (Corpus, error) {
+//
+// The languages are the codes of the languages the site is published in, if
+// any. The returned corpus is in the first language.
+func LoadCorpus(dir string, languages []string) (Corpus, error) {
 	corpus := Corpus{
-		entries: map[string]Entry{},
-		used:    map[string]bool{},
+		languages:  languages,
+		byLanguage: map[string]map[string]Entry{},
+		used:       map[string]bool{},
+		related:    &related{weights: DefaultRelatedWeights()},
+	}
+	if len(languages) > 0 {
+		corpus.language = languages[0]
+	}
+	for _, lang := range append([]string{corpus.language}, languages...) {
+		corpus.byLanguage[lang] = map[string]Entry{}
 	}
+	corpus.entries = corpus.byLanguage[corpus.language]
 
 	seq, finish := util.WalkFiles(dir)
 	for path := range seq {
@@ -52,7 +90,18 @@ func LoadCorpus(dir string) (Corpus, error) {
 			return corpus, err
 		}
 
-		corpus.entries[m.key] = Entry{
+		m.key, m.Language = SplitLanguage(m.key, languages)
+
+		entries := corpus.byLanguage[m.Language]
+		if other, ok := entries[m.key]; ok {
+			return corpus, fmt.Errorf(
+				"content files \"%s\" and \"%s\" have the same key",
+				other.Filepath,
+				m.Filepath,
+			)
+		}
+
+		entries[m.key] = Entry{
 			Metadata: m,
 			corpus:   &corpus,
 		}
@@ -66,6 +115,29 @@ func LoadCorpus(dir string) (Corpus, error) {
 	return corpus, nil
 }
 
+// Returns a copy of the corpus giving access to the content in the given
+// language.
+func (c Corpus) InLanguage(lang string) Corpus {
+	entries, ok := c.byLanguage[lang]
+	if !ok {
+		entries = map[string]Entry{}
+	}
+
+	c.entries = entries
+	c.language = lang
+	return c
+}
+
+// Returns the code of the current language.
+func (c Corpus) Language() string {
+	return c.language
+}
+
+// Returns the codes of all the languages, the default first.
+func (c Corpus) Languages() []string {
+	return c.languages
+}
+
 func (c Corpus) Get(key string) (Content, error) {
 	entry, ok := c.entries[key]
 	if !ok {
@@ -75,15 +147,28 @@ func (c Corpus) Get(key string) (Content, error) {
 		}
 	}
 
-	// Record that we used this content file
-	c.used[key] = true
+	return c.load(entry.Metadata)
+}
+
+// Loads the content and records that it was used.
+//
+// Any content it includes is loaded and recorded as used too.
+func (c Corpus) load(m Metadata) (Content, error) {
+	slog.Debug("loading content from disk", "path", m.Filepath)
+	c.used[m.Filepath] = true
 
-	content, err := LoadContent(entry.Metadata)
+	text, err := c.includeText(m, nil)
 	if err != nil {
-		return content, err
+		return Content{Metadata: m}, err
 	}
 
-	return content, nil
+	return parseContent(m, text)
+}
+
+// Returns true if there is content with the given key.
+func (c Corpus) Has(key string) bool {
+	_, ok := c.entries[key]
+	return ok
 }
 
 func (c Corpus) GetMaybe(key string) (*Content, error) {
@@ -137,13 +222,61 @@ func (c Corpus) sorted(sortFunc func(Entry, Entry) int) iter.Seq[Entry] {
 	return slices.Values(values)
 }
 
+// Warns about every piece of content that no page used.
+//
 // This is a function rather than a method so it can't be called by users
 // within templates.
-func ReportUnused(c Corpus) {
-	for entry := range c.All() {
-		_, ok := c.used[entry.Key()]
-		if !ok {
-			slog.Warn("unused content", "path", entry.Filepath)
+func ReportUnused(c Corpus, logger *slog.Logger) {
+	for _, entries := range c.byLanguage {
+		for _, entry := range entries {
+			_, ok := c.used[entry.Filepath]
+			if !ok {
+				logger.Warn("unused content", "path", entry.Filepath)
+			}
+		}
+	}
+}
+
+// Returns a map from every label defined in the corpus to the key of the
+// content that defines it.
+//
+// Only content in the current language is indexed.
+//
+// This parses every content file, but doesn't count as using the content.
+// Labels defined more than once are warned about.
+func IndexLabels(c Corpus, logger *slog.Logger) (map[string]string, error) {
+	index := map[string]string{}
+
+	for _, key := range slices.Sorted(maps.Keys(c.entries)) {
+		content, err := LoadContent(c.entries[key].Metadata)
+		if err != nil {
+			return index, err
+		}
+
+		labels, err := content.Root.Labels()
+		if err != nil {
+			return index, fmt.Errorf(
+				"failed to index labels in content file \"%s\": %w",
+				content.Filepath,
+				err,
+			)
+		}
+
+		for _, label := range labels {
+			if other, ok := index[label]; ok && other != key {
+				logger.Warn(
+					"duplicate label",
+					"label",
+					label,
+					"path",
+					content.Filepath,
+				)
+				continue
+			}
+
+			index[label] = key
 		}
 	}
+
+	return index, nil
 }
@@ -0,0 +1,306 @@
+package content_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/content"
+	"github.com/sinclairtarget/michel/internal/merrors"
+	"github.com/sinclairtarget/michel/internal/testutil"
+)
+
+// Writes the content files to a temporary directory and loads them.
+func loadTestCorpus(
+	t *testing.T,
+	files map[string]string,
+	languages ...string,
+) content.Corpus {
+	t.Helper()
+
+	tmpdir := testutil.TempFiles(t, files)
+	corpus, err := content.LoadCorpus(tmpdir, languages)
+	if err != nil {
+		t.Fatalf("failed to load corpus: %v", err)
+	}
+
+	return corpus
+}
+
+// Every label defined in a content file should be indexed under that file's
+// key.
+func TestIndexLabels(t *testing.T) {
+	files := map[string]string{
+		"intro.md": `---
+title: Intro
+---
+(intro-label)=
+# Introduction
+See {ref}` + "`setup-label`" + `.
+`,
+		"guides/setup.md": `---
+title: Setup
+---
+(setup-label)=
+## Setup
+`,
+	}
+
+	corpus := loadTestCorpus(t, files)
+
+	index, err := content.IndexLabels(corpus, slog.Default())
+	if err != nil {
+		t.Fatalf("failed to index labels: %v", err)
+	}
+
+	expected := map[string]string{
+		"intro-label": "intro",
+		"setup-label": "guides/setup",
+	}
+	for label, key := range expected {
+		if index[label] != key {
+			t.Errorf(
+				"label \"%s\" indexed incorrectly; wanted \"%s\", got \"%s\"",
+				label,
+				key,
+				index[label],
+			)
+		}
+	}
+}
+
+// Related content should be ordered by shared tags, then by shared section.
+// Drafts and unrelated content should be left out.
+func TestRelated(t *testing.T) {
+	files := map[string]string{
+		"posts/go/generics.md": `---
+title: Generics
+tags: [go, types]
+---
+`,
+		"posts/go/channels.md": `---
+title: Channels
+tags: [go]
+---
+`,
+		"posts/go/errors.md": `---
+title: Errors
+---
+`,
+		"posts/rust/traits.md": `---
+title: Traits
+tags: [types]
+---
+`,
+		"posts/go/draft.md": `---
+title: Draft
+tags: [go, types]
+draft: true
+---
+`,
+		"about.md": `---
+title: About
+---
+`,
+	}
+
+	corpus := loadTestCorpus(t, files)
+	content.SetRelatedWeights(corpus, content.RelatedWeights{
+		Tags:    1,
+		Section: 0.5,
+	})
+
+	entry, err := corpus.Get("posts/go/generics")
+	if err != nil {
+		t.Fatalf("failed to get content: %v", err)
+	}
+
+	seq, err := corpus.Related(entry, 3)
+	if err != nil {
+		t.Fatalf("failed to find related content: %v", err)
+	}
+
+	keys := []string{}
+	for e := range seq {
+		keys = append(keys, e.Key())
+	}
+
+	expected := []string{
+		"posts/go/channels",
+		"posts/rust/traits",
+		"posts/go/errors",
+	}
+	if !slices.Equal(keys, expected) {
+		t.Errorf(
+			"related content incorrect; wanted %v, got %v",
+			expected,
+			keys,
+		)
+	}
+}
+
+// Content can be given a language by suffix or by directory. Translations
+// share a key.
+func TestLoadCorpusLanguages(t *testing.T) {
+	files := map[string]string{
+		"post.md": `---
+title: Post
+---
+`,
+		"post.de.md": `---
+title: Beitrag
+---
+`,
+		"de/about.md": `---
+title: Über
+---
+`,
+	}
+
+	corpus := loadTestCorpus(t, files, "en", "de")
+
+	expected := map[string]string{"post": "Post"}
+	checkTitles(t, "en", corpus.InLanguage("en"), expected)
+
+	expected = map[string]string{"post": "Beitrag", "about": "Über"}
+	checkTitles(t, "de", corpus.InLanguage("de"), expected)
+
+	post, err := corpus.InLanguage("de").Get("post")
+	if err != nil {
+		t.Fatalf("failed to get content: %v", err)
+	}
+	if post.Language != "de" {
+		t.Errorf("language incorrect; wanted \"de\", got \"%s\"", post.Language)
+	}
+
+	for entry := range corpus.All() {
+		translations := entry.Translations()
+		if len(translations) != 1 || translations[0].Title != "Beitrag" {
+			t.Errorf(
+				"translations incorrect; wanted [Beitrag], got %v",
+				translations,
+			)
+		}
+	}
+}
+
+func checkTitles(
+	t *testing.T,
+	lang string,
+	corpus content.Corpus,
+	expected map[string]string,
+) {
+	t.Helper()
+
+	titles := map[string]string{}
+	for entry := range corpus.All() {
+		titles[entry.Key()] = entry.Title
+	}
+
+	if !maps.Equal(titles, expected) {
+		t.Errorf(
+			"content in \"%s\" incorrect; wanted %v, got %v",
+			lang,
+			expected,
+			titles,
+		)
+	}
+}
+
+// Include directives should be replaced by the included content, except in
+// code blocks.
+func TestInclude(t *testing.T) {
+	files := map[string]string{
+		"post.md": "# Post\n\n:::{include} snippets/license\n:::\n\n" +
+			"```\n:::{include} snippets/missing\n:::\n```\n",
+		"snippets/license.md": "---\ntitle: License\n---\nAll rights reserved.\n",
+	}
+
+	corpus := loadTestCorpus(t, files)
+
+	post, err := corpus.Get("post")
+	if err != nil {
+		t.Fatalf("failed to get content: %v", err)
+	}
+
+	text, err := post.Root.PlainText()
+	if err != nil {
+		t.Fatalf("failed to get plain text: %v", err)
+	}
+	if !strings.Contains(text, "All rights reserved.") {
+		t.Errorf("included text missing; wanted license, got:\n%s", text)
+	}
+}
+
+// Include directives naming a file should include it by its path relative to
+// the including file, and count it as used.
+func TestIncludeFile(t *testing.T) {
+	files := map[string]string{
+		"guides/post.md": "# Post\n\n:::{include} ../shared/notice.md\n:::\n",
+		"shared/notice.md": "---\ntitle: Notice\n---\n" +
+			":::{include} footer\n:::\n",
+		"footer.md": "Shared footer.\n",
+	}
+
+	corpus := loadTestCorpus(t, files)
+
+	post, err := corpus.Get("guides/post")
+	if err != nil {
+		t.Fatalf("failed to get content: %v", err)
+	}
+
+	text, err := post.Root.PlainText()
+	if err != nil {
+		t.Fatalf("failed to get plain text: %v", err)
+	}
+	if !strings.Contains(text, "Shared footer.") {
+		t.Errorf("included text missing; wanted footer, got:\n%s", text)
+	}
+
+	var logs bytes.Buffer
+	content.ReportUnused(corpus, slog.New(slog.NewTextHandler(&logs, nil)))
+	if logs.Len() > 0 {
+		t.Errorf("included content reported unused; got %s", logs.String())
+	}
+}
+
+func TestIncludeFileMissing(t *testing.T) {
+	files := map[string]string{
+		"post.md": ":::{include} missing.md\n:::\n",
+	}
+
+	corpus := loadTestCorpus(t, files)
+
+	_, err := corpus.Get("post")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("wanted file not found error, got %v", err)
+	}
+}
+
+// Content that includes itself should be rejected.
+func TestIncludeCycle(t *testing.T) {
+	files := map[string]string{
+		"a.md": ":::{include} b\n:::\n",
+		"b.md": ":::{include} a\n:::\n",
+	}
+
+	corpus := loadTestCorpus(t, files)
+
+	_, err := corpus.Get("a")
+
+	var cycleErr merrors.IncludeCycleError
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("wanted include cycle error, got %v", err)
+	}
+	if len(cycleErr.Chain) != 3 {
+		t.Errorf(
+			"cycle incorrect; wanted a -> b -> a, got %v",
+			cycleErr.Chain,
+		)
+	}
+}
@@ -0,0 +1,184 @@
+package content
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sinclairtarget/michel/internal/merrors"
+)
+
+// Matches the opening line of an include directive, capturing the fence and
+// the key or path of the content to include, e.g.
+// ":::{include} snippets/license".
+var includePattern = regexp.MustCompile(
+	"^[ ]{0,3}(`{3,}|:{3,})\\{include\\}[ \\t]+(\\S+)[ \\t]*$",
+)
+
+// Matches a fence line, capturing the fence and anything after it.
+var fencePattern = regexp.MustCompile("^[ ]{0,3}(`{3,}|~{3,}|:{3,})(.*)$")
+
+// A fenced block we are inside of while scanning for include directives.
+type fence struct {
+	marker string // e.g. "```"
+	code   bool   // Code blocks are left alone
+}
+
+// Returns true if the line closes the fenced block.
+func (f fence) closedBy(line string) bool {
+	match := fencePattern.FindStringSubmatch(line)
+	return match != nil &&
+		match[1][0] == f.marker[0] &&
+		len(match[1]) >= len(f.marker) &&
+		strings.TrimSpace(match[2]) == ""
+}
+
+// Returns the text of the content with every include directive replaced by
+// the text of the content it names, recursively.
+//
+// An include directive is a MyST directive naming the key of other content in
+// the same language:
+//
+//	:::{include} snippets/license
+//	:::
+//
+// As in standard MyST, the directive can instead name a file by a path
+// relative to the including file, e.g. "../shared.md". Anything with a file
+// extension is taken to be a path.
+//
+// Anything in the body of the directive is ignored. Directives inside code
+// blocks are left alone. Included content counts as used.
+//
+// The included text is spliced in before parsing, which has some limitations:
+//   - Relative links and images in included text are resolved against the
+//     including file's directory, not the included file's.
+//   - Labels in included text are indexed by IndexLabels under the included
+//     content's key, not the including content's.
+//   - Line numbers in errors about the including content count the included
+//     lines.
+//
+// The stack holds the paths of the content including this content.
+func (c Corpus) includeText(m Metadata, stack []string) (string, error) {
+	stack = append(stack, m.Filepath)
+
+	text, err := readText(m.Filepath)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		sb      strings.Builder
+		fences  []fence
+		skipTo  *fence // Closing fence of the include directive we're in
+		lineNum int
+	)
+	for line := range strings.Lines(text) {
+		lineNum += 1
+		trimmed := strings.TrimRight(line, "\r\n")
+
+		if skipTo != nil {
+			if skipTo.closedBy(trimmed) {
+				skipTo = nil
+			}
+			continue
+		}
+
+		inCode := len(fences) > 0 && fences[len(fences)-1].code
+		if !inCode {
+			if match := includePattern.FindStringSubmatch(trimmed); match != nil {
+				included, err := c.include(match[2], m, lineNum, stack)
+				if err != nil {
+					return "", err
+				}
+
+				sb.WriteString(included)
+				if !strings.HasSuffix(included, "\n") {
+					sb.WriteString("\n")
+				}
+				skipTo = &fence{marker: match[1]}
+				continue
+			}
+		}
+
+		if len(fences) > 0 && fences[len(fences)-1].closedBy(trimmed) {
+			fences = fences[:len(fences)-1]
+		} else if !inCode {
+			match := fencePattern.FindStringSubmatch(trimmed)
+			if match != nil {
+				fences = append(fences, fence{
+					marker: match[1],
+					code:   !strings.HasPrefix(strings.TrimSpace(match[2]), "{"),
+				})
+			}
+		}
+
+		sb.WriteString(line)
+	}
+
+	return sb.String(), nil
+}
+
+// Returns the text of the content with the given key or path, for including
+// in the given content at the given line.
+func (c Corpus) include(
+	key string,
+	from Metadata,
+	line int,
+	stack []string,
+) (string, error) {
+	if filepath.Ext(key) != "" {
+		return c.includeFile(key, from, line, stack)
+	}
+
+	entry, ok := c.byLanguage[from.Language][key]
+	if !ok {
+		return "", fmt.Errorf(
+			"failed to include content at line %d of \"%s\": %w",
+			line,
+			from.Filepath,
+			&merrors.KeyNotFoundError{Key: key, Type: "content"},
+		)
+	}
+
+	if slices.Contains(stack, entry.Filepath) {
+		return "", merrors.IncludeCycleError{
+			Chain: append(slices.Clone(stack), entry.Filepath),
+		}
+	}
+
+	c.used[entry.Filepath] = true
+	return c.includeText(entry.Metadata, stack)
+}
+
+// Returns the text of the file at the given path, relative to the given
+// content, for including in the content at the given line.
+func (c Corpus) includeFile(
+	path string,
+	from Metadata,
+	line int,
+	stack []string,
+) (string, error) {
+	path = filepath.Join(filepath.Dir(from.Filepath), filepath.FromSlash(path))
+	if slices.Contains(stack, path) {
+		return "", merrors.IncludeCycleError{
+			Chain: append(slices.Clone(stack), path),
+		}
+	}
+
+	m := Metadata{Filepath: path, Language: from.Language}
+	text, err := c.includeText(m, stack)
+	if err != nil {
+		return "", fmt.Errorf(
+			"failed to include file at line %d of \"%s\": %w",
+			line,
+			from.Filepath,
+			err,
+		)
+	}
+
+	// The file might be content in its own right
+	c.used[path] = true
+	return text, nil
+}
@@ -0,0 +1,38 @@
+package content
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Splits the language out of a content key.
+//
+// Content can be given a language either with a suffix (post.de.md) or by
+// putting it under a directory named for the language (de/post.md). Content
+// with neither is in the first (default) language. Returns the key without the
+// language and the language code.
+//
+// If there are no languages, the key is returned unchanged with an empty
+// language code.
+func SplitLanguage(key string, languages []string) (string, string) {
+	if len(languages) == 0 {
+		return key, ""
+	}
+
+	lang := languages[0]
+
+	first, rest, found := strings.Cut(key, string(filepath.Separator))
+	if found && slices.Contains(languages, first) {
+		key = rest
+		lang = first
+	}
+
+	ext := filepath.Ext(key)
+	if ext != "" && slices.Contains(languages, ext[1:]) {
+		key = strings.TrimSuffix(key, ext)
+		lang = ext[1:]
+	}
+
+	return key, lang
+}
@@ -1,7 +1,10 @@
 package myst
 
 import (
+	"encoding/json"
+	"fmt"
 	"iter"
+	"slices"
 
 	atrus "github.com/sinclairtarget/libatrus-go"
 )
@@ -12,8 +15,12 @@ import (
 // We wrap the basic node with helper methods for traversing the AST.
 type Node struct {
 	atrus.ASTNode
+	source string // path of the file the AST was parsed from, if any
 }
 
+// Returns the path of the file the AST was parsed from.
+func (n *Node) Source() string { return n.source }
+
 // Returns an iterator over all nodes in the AST rooted at the given node that
 // match the given type.
 //
@@ -28,7 +35,7 @@ func (n *Node) All(nodeType string) iter.Seq[*Node] {
 		}
 
 		for _, child := range n.Children() {
-			wrapped := Node{*child}
+			wrapped := Node{ASTNode: *child, source: n.source}
 			for match := range wrapped.All(nodeType) {
 				if !yield(match) {
 					return
@@ -49,3 +56,92 @@ func (n *Node) First(nodeType string) *Node {
 
 	return nil
 }
+
+// Properties of a MyST AST node beyond its type and children.
+//
+// libatrus only exposes node types and children directly, so we decode the
+// JSON rendering of the AST to get at everything else.
+type data struct {
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+	Children []data `json:"children"`
+	// Labelled nodes and cross-references
+	Identifier string `json:"identifier"`
+	Label      string `json:"label"`
+	// Code blocks
+	Lang               string `json:"lang"`
+	ShowLineNumbers    bool   `json:"showLineNumbers"`
+	StartingLineNumber int    `json:"startingLineNumber"`
+	EmphasizeLines     []int  `json:"emphasizeLines"`
+}
+
+// Decodes the AST rooted at the given node.
+func (n *Node) decode() (data, error) {
+	var d data
+
+	s, err := atrus.RenderJSON(&n.ASTNode, atrus.JSONOpts{})
+	if err != nil {
+		return d, fmt.Errorf("libatrus render error: %w", err)
+	}
+
+	err = json.Unmarshal([]byte(s), &d)
+	if err != nil {
+		return d, fmt.Errorf("failed to decode MyST AST: %w", err)
+	}
+
+	return d, nil
+}
+
+// Returns all nodes in the decoded AST that match any of the given types, in
+// pre-order.
+func (d data) all(nodeTypes ...string) []data {
+	matches := []data{}
+	if slices.Contains(nodeTypes, d.Type) {
+		matches = append(matches, d)
+	}
+
+	for _, child := range d.Children {
+		matches = append(matches, child.all(nodeTypes...)...)
+	}
+
+	return matches
+}
+
+// Node types that carry an identifier but refer to something else rather than
+// being the target of a reference.
+//
+// Link and image reference definitions ("[x]: https://...") have identifiers
+// too, but they name URLs, not places in the document.
+var referenceTypes = []string{
+	"crossReference",
+	"footnoteReference",
+	"footnoteDefinition",
+	"definition",
+	"linkReference",
+	"imageReference",
+	"cite",
+}
+
+// Returns the identifiers of all labelled nodes (e.g. headings, figures,
+// equations) in the AST rooted at the given node.
+func (n *Node) Labels() ([]string, error) {
+	d, err := n.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.labels(), nil
+}
+
+func (d data) labels() []string {
+	labels := []string{}
+	if d.Identifier != "" && !slices.Contains(referenceTypes, d.Type) {
+		labels = append(labels, d.Identifier)
+	}
+
+	for _, child := range d.Children {
+		labels = append(labels, child.labels()...)
+	}
+
+	return labels
+}
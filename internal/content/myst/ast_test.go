@@ -0,0 +1,38 @@
+package myst_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/content/myst"
+)
+
+// Only nodes that can be the target of a cross-reference should count as
+// labelled, not the many other nodes that carry identifiers.
+func TestLabels(t *testing.T) {
+	ast := `{"type": "root", "children": [
+		{"type": "heading", "identifier": "intro", "children": [
+			{"type": "text", "value": "Intro"}
+		]},
+		{"type": "paragraph", "children": [
+			{"type": "crossReference", "identifier": "setup"},
+			{"type": "linkReference", "identifier": "docs"},
+			{"type": "imageReference", "identifier": "logo"},
+			{"type": "footnoteReference", "identifier": "1"},
+			{"type": "cite", "identifier": "knuth1984"}
+		]},
+		{"type": "container", "identifier": "fig-1", "children": [
+			{"type": "image"}
+		]},
+		{"type": "math", "identifier": "euler", "value": "e^{i\\pi} = -1"},
+		{"type": "definition", "identifier": "docs"},
+		{"type": "definition", "identifier": "logo"},
+		{"type": "footnoteDefinition", "identifier": "1"}
+	]}`
+
+	labels := myst.LabelsOf(ast)
+	expected := []string{"intro", "fig-1", "euler"}
+	if !slices.Equal(labels, expected) {
+		t.Errorf("labels incorrect; wanted %v, got %v", expected, labels)
+	}
+}
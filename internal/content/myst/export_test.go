@@ -0,0 +1,47 @@
+package myst
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+)
+
+// Decodes a MyST AST from its JSON rendering, for tests of functions that
+// operate on decoded ASTs without going through libatrus.
+func decodeJSON(ast string) data {
+	var d data
+	err := json.Unmarshal([]byte(ast), &d)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func Highlight(rendered string, ast string, lineNumbers bool) (string, error) {
+	return highlight(rendered, decodeJSON(ast), lineNumbers, slog.Default())
+}
+
+func RenderMath(rendered string, ast string) (string, error) {
+	return renderMath(rendered, decodeJSON(ast), slog.Default())
+}
+
+var NumberEquationRefs = numberEquationRefs
+
+func ExtractTextOf(ast string, opts TextOpts) string {
+	var b strings.Builder
+	writeText(&b, decodeJSON(ast), opts)
+	return strings.TrimSpace(b.String())
+}
+
+func LabelsOf(ast string) []string {
+	return decodeJSON(ast).labels()
+}
+
+func ResolveLinks(
+	rendered string,
+	ast string,
+	source string,
+	resolver Resolver,
+) (string, error) {
+	return resolveLinks(rendered, decodeJSON(ast), source, resolver)
+}
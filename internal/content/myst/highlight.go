@@ -0,0 +1,118 @@
+package myst
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// Matches a code block in HTML rendered by libatrus.
+var codeBlockPattern = regexp.MustCompile(`(?s)<pre[^>]*>\s*<code[^>]*>.*?</code>\s*</pre>`)
+
+// Replaces each code block in the rendered HTML with a syntax-highlighted
+// version.
+//
+// libatrus renders code blocks itself, so we pair each rendered code block
+// with the corresponding code node in the AST by order of appearance. The AST
+// gives us the language, the unescaped source, and the options set by the
+// {code-block} directive.
+func highlight(
+	rendered string,
+	root data,
+	lineNumbers bool,
+	logger *slog.Logger,
+) (string, error) {
+	nodes := root.all("code")
+	matches := codeBlockPattern.FindAllStringIndex(rendered, -1)
+	if len(matches) != len(nodes) {
+		logger.Warn(
+			"skipping syntax highlighting; could not match code blocks",
+			"rendered",
+			len(matches),
+			"parsed",
+			len(nodes),
+		)
+		return rendered, nil
+	}
+
+	var b strings.Builder
+	prev := 0
+	for i, match := range matches {
+		b.WriteString(rendered[prev:match[0]])
+
+		err := highlightCode(&b, nodes[i], lineNumbers)
+		if err != nil {
+			return "", err
+		}
+
+		prev = match[1]
+	}
+	b.WriteString(rendered[prev:])
+
+	return b.String(), nil
+}
+
+func highlightCode(w io.Writer, node data, lineNumbers bool) error {
+	lexer := lexers.Get(node.Lang)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	start := node.StartingLineNumber
+	if start == 0 {
+		start = 1
+	}
+
+	// emphasize-lines counts from the first line of the block, whereas
+	// chroma counts from the base line number.
+	ranges := [][2]int{}
+	for _, line := range node.EmphasizeLines {
+		n := start + line - 1
+		ranges = append(ranges, [2]int{n, n})
+	}
+
+	formatter := html.New(
+		html.WithClasses(true),
+		html.WithLineNumbers(lineNumbers || node.ShowLineNumbers),
+		html.BaseLineNumber(start),
+		html.HighlightLines(ranges),
+	)
+
+	iterator, err := lexer.Tokenise(nil, node.Value)
+	if err != nil {
+		return fmt.Errorf(
+			"failed to tokenize code block with language \"%s\": %w",
+			node.Lang,
+			err,
+		)
+	}
+
+	return formatter.Format(w, styles.Fallback, iterator)
+}
+
+// Writes the CSS stylesheet for the named syntax highlighting style.
+//
+// Highlighted code blocks are rendered with CSS classes, so one of these
+// stylesheets needs to be included in the site for the highlighting to show.
+func WriteHighlightCSS(w io.Writer, styleName string) error {
+	style, ok := styles.Registry[strings.ToLower(styleName)]
+	if !ok {
+		return fmt.Errorf("unknown highlight style \"%s\"", styleName)
+	}
+
+	formatter := html.New(html.WithClasses(true))
+	return formatter.WriteCSS(w, style)
+}
+
+// Returns the names of the available syntax highlighting styles.
+func HighlightStyles() []string {
+	return styles.Names()
+}
@@ -0,0 +1,163 @@
+package myst_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/content/myst"
+)
+
+func TestHighlightPairsCodeBlocks(t *testing.T) {
+	rendered := "<h1>Title</h1>\n" +
+		`<pre><code class="language-go">func f() {}</code></pre>` +
+		"\n<p>Between</p>\n" +
+		`<pre><code class="language-python">def f(): pass</code></pre>` +
+		"\n<p>After</p>"
+	ast := `{"type": "root", "children": [
+		{"type": "code", "lang": "go", "value": "func f() {}"},
+		{"type": "paragraph", "children": [{"type": "text", "value": "x"}]},
+		{"type": "code", "lang": "python", "value": "def f(): pass"}
+	]}`
+
+	html, err := myst.Highlight(rendered, ast, false)
+	if err != nil {
+		t.Fatalf("failed to highlight: %v", err)
+	}
+
+	blocks := strings.Split(html, "<p>Between</p>")
+	if len(blocks) != 2 {
+		t.Fatalf("text between code blocks incorrect; got %s", html)
+	}
+
+	if !strings.HasPrefix(blocks[0], "<h1>Title</h1>\n") {
+		t.Errorf("text before code blocks incorrect; got %s", blocks[0])
+	}
+	if !strings.HasSuffix(blocks[1], "\n<p>After</p>") {
+		t.Errorf("text after code blocks incorrect; got %s", blocks[1])
+	}
+
+	// Go's "func" and Python's "def" are both keywords, but only Python's
+	// lexer knows "def".
+	goKeyword := `<span class="kd">func</span>`
+	pythonKeyword := `<span class="k">def</span>`
+	if !strings.Contains(blocks[0], goKeyword) {
+		t.Errorf("first block not highlighted as Go; got %s", blocks[0])
+	}
+	if !strings.Contains(blocks[1], pythonKeyword) {
+		t.Errorf("second block not highlighted as Python; got %s", blocks[1])
+	}
+}
+
+func TestHighlightMismatchedCodeBlocks(t *testing.T) {
+	rendered := `<pre><code class="language-go">x := 1</code></pre>`
+	ast := `{"type": "root", "children": [
+		{"type": "code", "lang": "go", "value": "x := 1"},
+		{"type": "code", "lang": "go", "value": "y := 2"}
+	]}`
+
+	html, err := myst.Highlight(rendered, ast, false)
+	if err != nil {
+		t.Fatalf("failed to highlight: %v", err)
+	}
+
+	if html != rendered {
+		t.Errorf(
+			"mismatched code blocks should be left alone; wanted %s, got %s",
+			rendered,
+			html,
+		)
+	}
+}
+
+func TestHighlightEmphasizeLines(t *testing.T) {
+	rendered := "<pre><code>a\nb\nc\n</code></pre>"
+	ast := `{"type": "root", "children": [{
+		"type": "code",
+		"value": "a\nb\nc",
+		"showLineNumbers": true,
+		"startingLineNumber": 10,
+		"emphasizeLines": [2]
+	}]}`
+
+	html, err := myst.Highlight(rendered, ast, false)
+	if err != nil {
+		t.Fatalf("failed to highlight: %v", err)
+	}
+
+	// emphasize-lines counts from the first line of the block, so line 2 is
+	// numbered 11.
+	expected := `<span class="line hl"><span class="ln">11</span>`
+	if !strings.Contains(html, expected) {
+		t.Errorf("emphasized line incorrect; wanted %s, got %s", expected, html)
+	}
+
+	if strings.Count(html, `class="line hl"`) != 1 {
+		t.Errorf("wrong number of emphasized lines; got %s", html)
+	}
+}
+
+func TestHighlightLineNumbers(t *testing.T) {
+	rendered := "<pre><code>a\n</code></pre>"
+	tests := []struct {
+		name        string
+		ast         string
+		lineNumbers bool
+		expected    bool
+	}{
+		{
+			name:     "off",
+			ast:      `{"type": "root", "children": [{"type": "code", "value": "a"}]}`,
+			expected: false,
+		},
+		{
+			name:        "config",
+			ast:         `{"type": "root", "children": [{"type": "code", "value": "a"}]}`,
+			lineNumbers: true,
+			expected:    true,
+		},
+		{
+			name: "directive",
+			ast: `{"type": "root", "children": [
+				{"type": "code", "value": "a", "showLineNumbers": true}
+			]}`,
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			html, err := myst.Highlight(rendered, test.ast, test.lineNumbers)
+			if err != nil {
+				t.Fatalf("failed to highlight: %v", err)
+			}
+
+			// Numbering starts at 1 unless the block says otherwise
+			numbered := strings.Contains(html, `<span class="ln">1</span>`)
+			if numbered != test.expected {
+				t.Errorf(
+					"line numbers incorrect; wanted %v, got %v in %s",
+					test.expected,
+					numbered,
+					html,
+				)
+			}
+		})
+	}
+}
+
+func TestWriteHighlightCSS(t *testing.T) {
+	var sb strings.Builder
+	err := myst.WriteHighlightCSS(&sb, "GitHub")
+	if err != nil {
+		t.Fatalf("failed to write stylesheet: %v", err)
+	}
+
+	if !strings.Contains(sb.String(), ".chroma .kd") {
+		t.Errorf("stylesheet missing keyword class; got %s", sb.String())
+	}
+
+	err = myst.WriteHighlightCSS(&sb, "no-such-style")
+	if err == nil {
+		t.Errorf("expected error for unknown style")
+	}
+}
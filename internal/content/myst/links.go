@@ -0,0 +1,89 @@
+package myst
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Resolves references and links that point outside of the document being
+// rendered.
+type Resolver interface {
+	// Returns the URL of the label, which is defined in another document.
+	ResolveRef(source string, label string) (string, error)
+
+	// Returns the URL of the file that the relative link points to, or an
+	// empty string if the link should be left as it is.
+	ResolveLink(source string, target string) (string, error)
+}
+
+// Matches the href attribute of a link, or the src attribute of an image, in
+// HTML rendered by libatrus.
+var hrefPattern = regexp.MustCompile(
+	`(<(?:a|img)\b[^>]*\b(?:href|src)=")([^"]*)(")`,
+)
+
+// Rewrites links and images in the rendered HTML that point to other files.
+//
+// References to labels that aren't defined in this document are looked up
+// using the resolver, as are relative links to other files.
+func resolveLinks(
+	rendered string,
+	root data,
+	source string,
+	resolver Resolver,
+) (string, error) {
+	local := map[string]bool{}
+	for _, label := range root.labels() {
+		local[label] = true
+	}
+
+	refs := map[string]bool{}
+	for _, ref := range root.all("crossReference") {
+		refs[ref.Identifier] = true
+	}
+
+	var resolveErr error
+	result := hrefPattern.ReplaceAllStringFunc(rendered, func(s string) string {
+		if resolveErr != nil {
+			return s
+		}
+
+		parts := hrefPattern.FindStringSubmatch(s)
+		open, href, close := parts[1], html.UnescapeString(parts[2]), parts[3]
+
+		var resolved string
+		if label, ok := strings.CutPrefix(href, "#"); ok {
+			if local[label] || !refs[label] {
+				return s
+			}
+
+			resolved, resolveErr = resolver.ResolveRef(source, label)
+		} else if isRelativeLink(href) {
+			resolved, resolveErr = resolver.ResolveLink(source, href)
+		}
+
+		if resolved == "" {
+			return s
+		}
+
+		return open + html.EscapeString(resolved) + close
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+
+	return result, nil
+}
+
+// Returns true if the link is a path relative to the current document.
+func isRelativeLink(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+
+	return u.Scheme == "" && u.Host == "" && u.Path != "" &&
+		!strings.HasPrefix(u.Path, "/")
+}
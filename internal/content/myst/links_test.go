@@ -0,0 +1,98 @@
+package myst_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/content/myst"
+)
+
+// Resolves labels and links from fixed maps. Anything missing resolves to an
+// empty string.
+type testResolver struct {
+	refs  map[string]string
+	links map[string]string
+}
+
+func (r testResolver) ResolveRef(source string, label string) (string, error) {
+	if label == "broken" {
+		return "", errors.New("broken reference")
+	}
+	return r.refs[label], nil
+}
+
+func (r testResolver) ResolveLink(source string, target string) (string, error) {
+	return r.links[target], nil
+}
+
+func TestResolveLinks(t *testing.T) {
+	resolver := testResolver{
+		refs: map[string]string{
+			"install": "/guides/setup.html#install",
+			"intro":   "/wrong.html#intro",
+		},
+		links: map[string]string{
+			"setup.md":     "/guides/setup.html",
+			"search.md?q=": "/search.html?a=1&b=2",
+		},
+	}
+	ast := `{"type": "root", "children": [
+		{"type": "heading", "identifier": "intro"},
+		{"type": "paragraph", "children": [
+			{"type": "crossReference", "identifier": "install"},
+			{"type": "crossReference", "identifier": "intro"}
+		]}
+	]}`
+
+	tests := map[string]string{
+		// Cross-reference to another document
+		`<a href="#install">Install</a>`: `<a href="/guides/setup.html#install">Install</a>`,
+		// Label defined in this document
+		`<a href="#intro">Intro</a>`: `<a href="#intro">Intro</a>`,
+		// Plain anchor that isn't a cross-reference
+		`<a href="#top">Top</a>`: `<a href="#top">Top</a>`,
+		// Relative links and images
+		`<a href="setup.md">Setup</a>`: `<a href="/guides/setup.html">Setup</a>`,
+		`<img src="setup.md">`:         `<img src="/guides/setup.html">`,
+		`<a href="search.md?q=">S</a>`: `<a href="/search.html?a=1&amp;b=2">S</a>`,
+		// Left alone
+		`<a href="../about/">About</a>`:        `<a href="../about/">About</a>`,
+		`<a href="https://example.com/">E</a>`: `<a href="https://example.com/">E</a>`,
+		`<a href="/absolute.html">A</a>`:       `<a href="/absolute.html">A</a>`,
+		`<a href="mailto:a@example.com">M</a>`: `<a href="mailto:a@example.com">M</a>`,
+		`<link href="setup.md" rel="next">`:    `<link href="setup.md" rel="next">`,
+	}
+
+	for input, expected := range tests {
+		result, err := myst.ResolveLinks(input, ast, "guides/intro.md", resolver)
+		if err != nil {
+			t.Errorf("failed to resolve links in %s: %v", input, err)
+			continue
+		}
+
+		if result != expected {
+			t.Errorf(
+				"resolved links in %s incorrect; wanted %s, got %s",
+				input,
+				expected,
+				result,
+			)
+		}
+	}
+}
+
+func TestResolveLinksError(t *testing.T) {
+	ast := `{"type": "root", "children": [
+		{"type": "crossReference", "identifier": "broken"}
+	]}`
+
+	_, err := myst.ResolveLinks(
+		`<a href="#broken">Broken</a>`,
+		ast,
+		"intro.md",
+		testResolver{},
+	)
+	if err == nil {
+		t.Errorf("expected error resolving broken reference")
+	}
+}
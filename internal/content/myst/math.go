@@ -0,0 +1,179 @@
+package myst
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+
+	"github.com/sinclairtarget/michel/internal/mathml"
+)
+
+// Matches the opening tag of a math or inlineMath node in HTML rendered by
+// libatrus.
+var mathPattern = regexp.MustCompile(
+	`<(div|span)\b[^>]*\bclass="[^"]*\bmath-(?:display|inline)\b[^"]*"[^>]*>`,
+)
+
+// Matches a link to an anchor in the same document.
+var anchorLinkPattern = regexp.MustCompile(
+	`(?s)(<a\b[^>]*\bhref="#([^"]*)"[^>]*>)(.*?)(</a>)`,
+)
+
+// Matches the text libatrus (or we) might already have put in an equation
+// reference.
+var equationNumberPattern = regexp.MustCompile(`^\(\d+\)$`)
+
+// Replaces each math and inlineMath node in the rendered HTML with MathML.
+//
+// Labelled equations are numbered in order of appearance, and links to them
+// (i.e. {eq} references) are given the equation number as their text.
+//
+// As with syntax highlighting, rendered math is paired with the corresponding
+// node in the AST by order of appearance.
+func renderMath(
+	rendered string,
+	root data,
+	logger *slog.Logger,
+) (string, error) {
+	nodes := root.all("math", "inlineMath")
+	matches := findMath(rendered)
+	if len(matches) != len(nodes) {
+		logger.Warn(
+			"skipping math rendering; could not match math nodes",
+			"rendered",
+			len(matches),
+			"parsed",
+			len(nodes),
+		)
+		return rendered, nil
+	}
+
+	numbers := map[string]int{}
+
+	var b strings.Builder
+	prev := 0
+	for i, match := range matches {
+		b.WriteString(rendered[prev:match[0]])
+		prev = match[1]
+
+		node := nodes[i]
+		display := node.Type == "math"
+
+		converted, err := mathml.Convert(node.Value, display)
+		if err != nil {
+			return "", err
+		}
+
+		if !display {
+			b.WriteString(`<span class="math-inline">`)
+			b.WriteString(converted)
+			b.WriteString("</span>")
+			continue
+		}
+
+		if node.Identifier == "" {
+			b.WriteString(`<div class="math-display">`)
+			b.WriteString(converted)
+			b.WriteString("</div>")
+			continue
+		}
+
+		numbers[node.Identifier] = len(numbers) + 1
+		fmt.Fprintf(
+			&b,
+			`<div class="math-display" id="%s">%s`+
+				`<span class="equation-number">(%d)</span></div>`,
+			html.EscapeString(node.Identifier),
+			converted,
+			numbers[node.Identifier],
+		)
+	}
+	b.WriteString(rendered[prev:])
+
+	return numberEquationRefs(b.String(), numbers), nil
+}
+
+// Returns the start and end offsets of each math element in the rendered HTML.
+//
+// Math elements can contain elements of the same kind (e.g. an equation
+// number in a span), so we walk the HTML to find the matching closing tag.
+func findMath(rendered string) [][2]int {
+	matches := [][2]int{}
+	prev := 0
+	for {
+		loc := mathPattern.FindStringSubmatchIndex(rendered[prev:])
+		if loc == nil {
+			return matches
+		}
+
+		start := prev + loc[0]
+		tag := rendered[prev+loc[2] : prev+loc[3]]
+		end, ok := closingTagEnd(rendered[start:], tag)
+		if !ok {
+			return matches
+		}
+
+		matches = append(matches, [2]int{start, start + end})
+		prev = start + end
+	}
+}
+
+// Returns the offset just past the tag closing the element the HTML begins
+// with, or false if the element is never closed.
+func closingTagEnd(s string, tag string) (int, bool) {
+	z := nethtml.NewTokenizer(strings.NewReader(s))
+	offset := 0
+	depth := 0
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			return 0, false
+		}
+		offset += len(z.Raw())
+
+		name, _ := z.TagName()
+		if string(name) != tag {
+			continue
+		}
+
+		switch tt {
+		case nethtml.StartTagToken:
+			depth += 1
+		case nethtml.EndTagToken:
+			depth -= 1
+			if depth == 0 {
+				return offset, true
+			}
+		}
+	}
+}
+
+// Sets the text of links to numbered equations to the equation number.
+//
+// Links with text the author wrote themselves are left alone.
+func numberEquationRefs(rendered string, numbers map[string]int) string {
+	if len(numbers) == 0 {
+		return rendered
+	}
+
+	return anchorLinkPattern.ReplaceAllStringFunc(rendered, func(s string) string {
+		parts := anchorLinkPattern.FindStringSubmatch(s)
+		open, target, text, close := parts[1], parts[2], parts[3], parts[4]
+
+		n, ok := numbers[html.UnescapeString(target)]
+		if !ok {
+			return s
+		}
+
+		text = strings.TrimSpace(text)
+		if text != "" && text != target && !equationNumberPattern.MatchString(text) {
+			return s
+		}
+
+		return fmt.Sprintf("%s(%d)%s", open, n, close)
+	})
+}
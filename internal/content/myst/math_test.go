@@ -0,0 +1,108 @@
+package myst_test
+
+import (
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/content/myst"
+	"github.com/sinclairtarget/michel/internal/mathml"
+)
+
+func TestRenderMath(t *testing.T) {
+	rendered := `<p>Inline <span class="math-inline">x</span> math.</p>` +
+		`<div class="math-display">y</div><p>After</p>`
+	ast := `{"type": "root", "children": [
+		{"type": "paragraph", "children": [
+			{"type": "text", "value": "Inline "},
+			{"type": "inlineMath", "value": "x"},
+			{"type": "text", "value": " math."}
+		]},
+		{"type": "math", "value": "y"},
+		{"type": "paragraph", "children": [{"type": "text", "value": "After"}]}
+	]}`
+
+	html, err := myst.RenderMath(rendered, ast)
+	if err != nil {
+		t.Fatalf("failed to render math: %v", err)
+	}
+
+	x, _ := mathml.Convert("x", false)
+	y, _ := mathml.Convert("y", true)
+	expected := `<p>Inline <span class="math-inline">` + x +
+		`</span> math.</p><div class="math-display">` + y +
+		`</div><p>After</p>`
+	if html != expected {
+		t.Errorf("rendered math incorrect; wanted %s, got %s", expected, html)
+	}
+}
+
+func TestRenderMathNestedElements(t *testing.T) {
+	rendered := `<div class="math-display" id="eq">` +
+		`<span class="a"><span class="b">x</span></span>` +
+		`<span class="equation-number">(1)</span>` +
+		`</div><p>After <span>text</span></p>`
+	ast := `{"type": "root", "children": [
+		{"type": "math", "value": "x", "identifier": "eq"},
+		{"type": "paragraph", "children": [{"type": "text", "value": "After"}]}
+	]}`
+
+	html, err := myst.RenderMath(rendered, ast)
+	if err != nil {
+		t.Fatalf("failed to render math: %v", err)
+	}
+
+	x, _ := mathml.Convert("x", true)
+	expected := `<div class="math-display" id="eq">` + x +
+		`<span class="equation-number">(1)</span></div>` +
+		`<p>After <span>text</span></p>`
+	if html != expected {
+		t.Errorf("rendered math incorrect; wanted %s, got %s", expected, html)
+	}
+}
+
+func TestRenderMathMismatched(t *testing.T) {
+	rendered := `<span class="math-inline">x</span>`
+	ast := `{"type": "root", "children": [
+		{"type": "inlineMath", "value": "x"},
+		{"type": "inlineMath", "value": "y"}
+	]}`
+
+	html, err := myst.RenderMath(rendered, ast)
+	if err != nil {
+		t.Fatalf("failed to render math: %v", err)
+	}
+
+	if html != rendered {
+		t.Errorf(
+			"mismatched math should be left alone; wanted %s, got %s",
+			rendered,
+			html,
+		)
+	}
+}
+
+func TestNumberEquationRefs(t *testing.T) {
+	numbers := map[string]int{"euler": 1, "pythagoras": 2}
+	tests := map[string]string{
+		`<a href="#euler"></a>`:             `<a href="#euler">(1)</a>`,
+		`<a href="#pythagoras">(7)</a>`:     `<a href="#pythagoras">(2)</a>`,
+		`<a href="#euler">euler</a>`:        `<a href="#euler">(1)</a>`,
+		`<a href="#euler">Euler's</a>`:      `<a href="#euler">Euler's</a>`,
+		`<a href="#other"></a>`:             `<a href="#other"></a>`,
+		`<a class="x" href="#euler"> </a>`:  `<a class="x" href="#euler">(1)</a>`,
+		`<a href="other.html#euler"></a>`:   `<a href="other.html#euler"></a>`,
+		`<p>no links</p>`:                   `<p>no links</p>`,
+		`<a href="#euler"></a> <a href=#x>`: `<a href="#euler">(1)</a> <a href=#x>`,
+	}
+
+	for input, expected := range tests {
+		result := myst.NumberEquationRefs(input, numbers)
+		if result != expected {
+			t.Errorf(
+				"numbered refs for %s incorrect; wanted %s, got %s",
+				input,
+				expected,
+				result,
+			)
+		}
+	}
+}
@@ -4,12 +4,22 @@ package myst
 import (
 	"fmt"
 	"html/template"
+	"log/slog"
 
 	atrus "github.com/sinclairtarget/libatrus-go"
+
+	"github.com/sinclairtarget/michel/internal/util"
 )
 
 // Parse MyST markdown into a MyST AST.
 func Parse(text string) (*Node, error) {
+	return ParseFile(text, "")
+}
+
+// Parse MyST markdown loaded from the file at the given path into a MyST AST.
+//
+// The path is used to resolve relative links and to report errors.
+func ParseFile(text string, path string) (*Node, error) {
 	opts := atrus.ParseOpts{
 		ParseLevel: atrus.ParseLevelPost,
 	}
@@ -18,16 +28,63 @@ func Parse(text string) (*Node, error) {
 		return nil, fmt.Errorf("libatrus parse error: %w", err)
 	}
 
-	return &Node{*root}, nil
+	return &Node{ASTNode: *root, source: path}, nil
 }
 
 // Render MyST AST to HTML.
 func RenderHTML(node *Node) (template.HTML, error) {
+	return Renderer{}.RenderHTML(node)
+}
+
+// Renders MyST ASTs to HTML, applying any configured build-time processing on
+// top of what libatrus does.
+type Renderer struct {
+	Highlight   bool // Syntax highlight code blocks using CSS classes
+	LineNumbers bool // Show line numbers for every highlighted code block
+	Math        bool // Render math to MathML
+	Resolver    Resolver
+	Logger      *slog.Logger // For warnings; defaults to slog.Default()
+}
+
+// Render MyST AST to HTML.
+func (r Renderer) RenderHTML(node *Node) (template.HTML, error) {
 	html, err := atrus.RenderHTML(&node.ASTNode)
 	if err != nil {
 		return "", fmt.Errorf("libatrus render error: %w", err)
 	}
 
+	if !r.Highlight && !r.Math && r.Resolver == nil {
+		return template.HTML(html), nil
+	}
+
+	root, err := node.decode()
+	if err != nil {
+		return "", err
+	}
+
+	logger := util.LoggerOrDefault(r.Logger)
+
+	if r.Highlight {
+		html, err = highlight(html, root, r.LineNumbers, logger)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if r.Math {
+		html, err = renderMath(html, root, logger)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if r.Resolver != nil {
+		html, err = resolveLinks(html, root, node.source, r.Resolver)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	return template.HTML(html), nil
 }
 
@@ -0,0 +1,94 @@
+package myst
+
+import (
+	"strings"
+)
+
+// Options for extracting plain text from a MyST AST.
+type TextOpts struct {
+	IncludeCode     bool // Include the contents of code blocks
+	IncludeComments bool // Include the contents of MyST comments
+	IncludeMath     bool // Include the LaTeX source of math
+}
+
+// Node types after which we insert a line break when extracting text, so that
+// words in adjacent blocks don't run together.
+var blockTypes = map[string]bool{
+	"paragraph":  true,
+	"heading":    true,
+	"blockquote": true,
+	"listItem":   true,
+	"code":       true,
+	"math":       true,
+	"comment":    true,
+	"tableCell":  true,
+	"caption":    true,
+	"definition": true,
+	"break":      true,
+}
+
+// Returns the text in the AST rooted at the given node, without markup.
+//
+// Code blocks, comments, and math are skipped.
+func (n *Node) PlainText() (string, error) {
+	return ExtractText(n, TextOpts{})
+}
+
+// Returns the text in the AST rooted at the given node, without markup.
+//
+// A pre-order traversal of the AST is performed and the text values of the
+// nodes are concatenated.
+func ExtractText(n *Node, opts TextOpts) (string, error) {
+	d, err := n.decode()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	writeText(&b, d, opts)
+	return strings.TrimSpace(b.String()), nil
+}
+
+func writeText(b *strings.Builder, d data, opts TextOpts) {
+	switch d.Type {
+	case "code":
+		if !opts.IncludeCode {
+			return
+		}
+	case "comment":
+		if !opts.IncludeComments {
+			return
+		}
+	case "math", "inlineMath":
+		if !opts.IncludeMath {
+			return
+		}
+	}
+
+	b.WriteString(d.Value)
+	for _, child := range d.Children {
+		writeText(b, child, opts)
+	}
+
+	if blockTypes[d.Type] {
+		b.WriteByte('\n')
+	}
+}
+
+// Returns the text of every heading in the AST rooted at the given node, in
+// order.
+func (n *Node) Headings() ([]string, error) {
+	d, err := n.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	headings := []string{}
+	for _, heading := range d.all("heading") {
+		var b strings.Builder
+		writeText(&b, heading, TextOpts{})
+		headings = append(headings, strings.TrimSpace(b.String()))
+	}
+
+	return headings, nil
+}
@@ -0,0 +1,54 @@
+package myst_test
+
+import (
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/content/myst"
+)
+
+// Code, comments, and math aren't prose, so they shouldn't count towards word
+// counts or go into the search index unless asked for.
+func TestExtractText(t *testing.T) {
+	ast := `{"type": "root", "children": [
+		{"type": "paragraph", "children": [
+			{"type": "text", "value": "Area is "},
+			{"type": "inlineMath", "value": "\\pi r^2"},
+			{"type": "text", "value": "."}
+		]},
+		{"type": "math", "value": "\\begin{aligned} \\frac{a}{b} \\end{aligned}"},
+		{"type": "code", "value": "x := 1"},
+		{"type": "comment", "value": "TODO"},
+		{"type": "paragraph", "children": [{"type": "text", "value": "End"}]}
+	]}`
+
+	tests := []struct {
+		name     string
+		opts     myst.TextOpts
+		expected string
+	}{
+		{
+			name:     "prose",
+			expected: "Area is .\nEnd",
+		},
+		{
+			name: "everything",
+			opts: myst.TextOpts{
+				IncludeCode:     true,
+				IncludeComments: true,
+				IncludeMath:     true,
+			},
+			expected: "Area is \\pi r^2.\n" +
+				"\\begin{aligned} \\frac{a}{b} \\end{aligned}\n" +
+				"x := 1\nTODO\nEnd",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			text := myst.ExtractTextOf(ast, test.opts)
+			if text != test.expected {
+				t.Errorf("text incorrect; wanted %q, got %q", test.expected, text)
+			}
+		})
+	}
+}
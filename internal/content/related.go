@@ -0,0 +1,216 @@
+package content
+
+import (
+	"iter"
+	"math"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/sinclairtarget/michel/internal/merrors"
+	"github.com/sinclairtarget/michel/internal/search"
+	"github.com/sinclairtarget/michel/internal/util"
+)
+
+// Weights for the signals used to score how related two pieces of content are.
+type RelatedWeights struct {
+	Tags    float64 // Shared tags
+	Section float64 // Shared leading directories in the key
+	Text    float64 // TF-IDF similarity of the plain text
+}
+
+// Returns the weights used when none are configured.
+func DefaultRelatedWeights() RelatedWeights {
+	return RelatedWeights{
+		Tags:    1,
+		Section: 0.5,
+		Text:    1,
+	}
+}
+
+// State for computing related content, shared by copies of a Corpus.
+type related struct {
+	weights RelatedWeights
+	vectors map[string]map[string]float64 // TF-IDF vectors by content path
+}
+
+// Sets the weights used by Corpus.Related.
+//
+// This is a function rather than a method so it can't be called by users
+// within templates.
+func SetRelatedWeights(c Corpus, weights RelatedWeights) {
+	c.related.weights = weights
+}
+
+// Returns up to n entries most related to the given content, most related
+// first.
+//
+// Entries are scored by shared tags, by how much of their section (the
+// directories in their key) they share, and by the similarity of their text.
+// Drafts and entries with nothing in common are never returned.
+func (c Corpus) Related(item util.Keyed, n int) (iter.Seq[Entry], error) {
+	entry, ok := c.entries[item.Key()]
+	if !ok {
+		return nil, &merrors.KeyNotFoundError{
+			Key:  item.Key(),
+			Type: "content",
+		}
+	}
+
+	weights := c.related.weights
+	if weights.Text != 0 && c.related.vectors == nil {
+		err := c.indexText()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	type scored struct {
+		entry Entry
+		score float64
+	}
+
+	candidates := []scored{}
+	for other := range c.All() {
+		if other.Key() == entry.Key() || other.Draft {
+			continue
+		}
+
+		score := weights.Tags*tagSimilarity(entry, other) +
+			weights.Section*sectionSimilarity(entry.Key(), other.Key())
+		if weights.Text != 0 {
+			score += weights.Text * cosineSimilarity(
+				c.related.vectors[entry.Filepath],
+				c.related.vectors[other.Filepath],
+			)
+		}
+
+		if score > 0 {
+			candidates = append(candidates, scored{entry: other, score: score})
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b scored) int {
+		if a.score != b.score {
+			if a.score > b.score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.entry.Key(), b.entry.Key())
+	})
+
+	results := []Entry{}
+	for i := 0; i < len(candidates) && i < n; i++ {
+		results = append(results, candidates[i].entry)
+	}
+	return slices.Values(results), nil
+}
+
+// Computes TF-IDF vectors for the plain text of every entry.
+//
+// Document frequencies are counted separately for each language. This parses
+// every content file, but doesn't count as using the content.
+func (c Corpus) indexText() error {
+	vectors := map[string]map[string]float64{}
+
+	for _, entries := range c.byLanguage {
+		counts := map[string]map[string]int{}
+		docFreq := map[string]int{}
+
+		for _, entry := range entries {
+			content, err := LoadContent(entry.Metadata)
+			if err != nil {
+				return err
+			}
+
+			text, err := content.Root.PlainText()
+			if err != nil {
+				return err
+			}
+
+			termCounts := map[string]int{}
+			for _, term := range search.Terms(text) {
+				if termCounts[term] == 0 {
+					docFreq[term] += 1
+				}
+				termCounts[term] += 1
+			}
+			counts[entry.Filepath] = termCounts
+		}
+
+		total := float64(len(entries))
+		for path, termCounts := range counts {
+			vectors[path] = map[string]float64{}
+			for term, count := range termCounts {
+				idf := math.Log(total / float64(docFreq[term]))
+				vectors[path][term] = float64(count) * idf
+			}
+		}
+	}
+
+	c.related.vectors = vectors
+	return nil
+}
+
+// Jaccard similarity of the tags of the two entries.
+func tagSimilarity(a Entry, b Entry) float64 {
+	if len(a.Tags) == 0 || len(b.Tags) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for _, tag := range a.Tags {
+		if slices.Contains(b.Tags, tag) {
+			shared += 1
+		}
+	}
+
+	union := len(a.Tags) + len(b.Tags) - shared
+	return float64(shared) / float64(union)
+}
+
+// Fraction of the leading directories in the two keys that are shared.
+//
+// Content at the top level isn't in any section.
+func sectionSimilarity(a string, b string) float64 {
+	dirsA := sectionDirs(a)
+	dirsB := sectionDirs(b)
+	if len(dirsA) == 0 || len(dirsB) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for shared < len(dirsA) && shared < len(dirsB) &&
+		dirsA[shared] == dirsB[shared] {
+		shared += 1
+	}
+
+	return float64(shared) / float64(max(len(dirsA), len(dirsB)))
+}
+
+func sectionDirs(key string) []string {
+	dir := path.Dir(key)
+	if dir == "." {
+		return nil
+	}
+
+	return strings.Split(dir, "/")
+}
+
+func cosineSimilarity(a map[string]float64, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for term, weight := range a {
+		dot += weight * b[term]
+		normA += weight * weight
+	}
+	for _, weight := range b {
+		normB += weight * weight
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
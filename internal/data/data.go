@@ -0,0 +1,258 @@
+/*
+* Package data loads structured data files for use in templates.
+*
+* Files under the data/ directory are loaded into a nested map. Each file is
+* stored under its key, with each directory in the key becoming a level of
+* nesting. For example, data/team/members.yaml is available as
+* .Data.team.members.
+*
+* YAML, JSON, TOML, and CSV files are supported. A CSV file becomes a list of
+* maps, one per row, keyed by the header row.
+ */
+package data
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sinclairtarget/michel/internal/util"
+)
+
+// Returned when a data file can't be parsed.
+type ParseError struct {
+	Path string
+	Line int // Zero if unknown
+	Err  error
+}
+
+func (e ParseError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Loads all data files under the directory into a nested map.
+//
+// If the directory doesn't exist, returns an empty map.
+func Load(dir string) (map[string]any, error) {
+	return LoadLayered([]string{dir}, slog.Default())
+}
+
+// Loads all data files under the directories into a nested map.
+//
+// Where more than one directory has a file with the same key, the file in the
+// earliest directory wins. Two files with the same key in one directory are
+// still an error. Directories that don't exist are skipped. Files with unknown
+// extensions are warned about and skipped, so they don't override anything.
+func LoadLayered(dirs []string, logger *slog.Logger) (map[string]any, error) {
+	result := map[string]any{}
+	files := map[string]bool{}
+
+	key := func(dir string, path string) string {
+		k := FileKey(dir, path)
+		if k == "" {
+			logger.Warn("ignoring data file with unknown extension", "path", path)
+		}
+		return k
+	}
+
+	layered, err := util.LayeredFiles(dirs, key)
+	if err != nil {
+		return result, err
+	}
+
+	for _, file := range layered {
+		path := file.Path
+		parse := parsers[strings.ToLower(filepath.Ext(path))]
+
+		slog.Debug("loading data file", "path", path)
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return result, err
+		}
+
+		value, err := parse(path, b)
+		if err != nil {
+			return result, err
+		}
+
+		parts := strings.Split(file.Key, string(filepath.Separator))
+		err = insert(result, files, parts, value)
+		if err != nil {
+			return result, fmt.Errorf(
+				"failed to load data file \"%s\": %w",
+				path,
+				err,
+			)
+		}
+	}
+
+	return result, nil
+}
+
+// Returns the key of the data file at the path under the directory.
+//
+// Returns an empty string if the file isn't a data file.
+func FileKey(dir string, path string) string {
+	if _, ok := parsers[strings.ToLower(filepath.Ext(path))]; !ok {
+		return ""
+	}
+
+	return util.KeyFromPath(dir, path)
+}
+
+// Stores the value in the nested map at the path given by the key parts.
+//
+// The files map records the keys already used by files, so that a file and a
+// directory with the same key can be told apart.
+func insert(
+	m map[string]any,
+	files map[string]bool,
+	parts []string,
+	value any,
+) error {
+	for i, part := range parts[:len(parts)-1] {
+		prefix := strings.Join(parts[:i+1], "/")
+		if files[prefix] {
+			return fmt.Errorf(
+				"key \"%s\" is used by both a file and a directory",
+				prefix,
+			)
+		}
+
+		child, ok := m[part].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			m[part] = child
+		}
+		m = child
+	}
+
+	key := strings.Join(parts, "/")
+	last := parts[len(parts)-1]
+	if _, ok := m[last]; ok {
+		if files[key] {
+			return fmt.Errorf("key \"%s\" is used by more than one file", key)
+		}
+		return fmt.Errorf(
+			"key \"%s\" is used by both a file and a directory",
+			key,
+		)
+	}
+
+	files[key] = true
+	m[last] = value
+	return nil
+}
+
+type parser func(path string, b []byte) (any, error)
+
+var parsers = map[string]parser{
+	".yaml": parseYAML,
+	".yml":  parseYAML,
+	".json": parseJSON,
+	".toml": parseTOML,
+	".csv":  parseCSV,
+}
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+func parseYAML(path string, b []byte) (any, error) {
+	var value any
+	err := yaml.Unmarshal(b, &value)
+	if err != nil {
+		parseErr := ParseError{Path: path, Err: err}
+		if match := yamlLinePattern.FindStringSubmatch(err.Error()); match != nil {
+			parseErr.Line, _ = strconv.Atoi(match[1])
+		}
+		return nil, parseErr
+	}
+
+	return value, nil
+}
+
+func parseJSON(path string, b []byte) (any, error) {
+	var value any
+	err := json.Unmarshal(b, &value)
+	if err != nil {
+		parseErr := ParseError{Path: path, Err: err}
+
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			parseErr.Line = lineAt(b, syntaxErr.Offset)
+		}
+		return nil, parseErr
+	}
+
+	return value, nil
+}
+
+func parseTOML(path string, b []byte) (any, error) {
+	value := map[string]any{}
+	err := toml.Unmarshal(b, &value)
+	if err != nil {
+		parseErr := ParseError{Path: path, Err: err}
+
+		var tomlErr toml.ParseError
+		if errors.As(err, &tomlErr) {
+			parseErr.Line = tomlErr.Position.Line
+			parseErr.Err = errors.New(tomlErr.Message)
+		}
+		return nil, parseErr
+	}
+
+	return value, nil
+}
+
+func parseCSV(path string, b []byte) (any, error) {
+	records, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		parseErr := ParseError{Path: path, Err: err}
+
+		var csvErr *csv.ParseError
+		if errors.As(err, &csvErr) {
+			parseErr.Line = csvErr.Line
+			parseErr.Err = csvErr.Err
+		}
+		return nil, parseErr
+	}
+
+	rows := []any{}
+	if len(records) == 0 {
+		return rows, nil
+	}
+
+	header := records[0]
+	for _, record := range records[1:] {
+		row := map[string]any{}
+		for i, field := range record {
+			row[header[i]] = field
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// Returns the line number of the byte offset.
+func lineAt(b []byte, offset int64) int {
+	offset = min(offset, int64(len(b)))
+	return bytes.Count(b[:offset], []byte("\n")) + 1
+}
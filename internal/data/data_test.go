@@ -0,0 +1,159 @@
+package data_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/data"
+	"github.com/sinclairtarget/michel/internal/testutil"
+)
+
+// Data files in every supported format should be loaded into a nested map
+// following their keys.
+func TestLoad(t *testing.T) {
+	dir := testutil.TempFiles(t, map[string]string{
+		"team/members.yaml": "- name: Ada\n- name: Grace\n",
+		"team/lead.json":    `{"name": "Ada"}`,
+		"site.toml":         "title = \"Michel\"\n",
+		"changelog.csv":     "version,date\n1.0,2025-01-01\n",
+	})
+
+	result, err := data.Load(dir)
+	if err != nil {
+		t.Fatalf("failed to load data: %v", err)
+	}
+
+	expected := map[string]any{
+		"team": map[string]any{
+			"members": []any{
+				map[string]any{"name": "Ada"},
+				map[string]any{"name": "Grace"},
+			},
+			"lead": map[string]any{"name": "Ada"},
+		},
+		"site": map[string]any{"title": "Michel"},
+		"changelog": []any{
+			map[string]any{"version": "1.0", "date": "2025-01-01"},
+		},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("data incorrect; wanted %v, got %v", expected, result)
+	}
+}
+
+// A missing data directory isn't an error.
+func TestLoadMissingDir(t *testing.T) {
+	result, err := data.Load(filepath.Join(t.TempDir(), "data"))
+	if err != nil {
+		t.Fatalf("failed to load data: %v", err)
+	}
+
+	if len(result) != 0 {
+		t.Errorf("data incorrect; wanted empty map, got %v", result)
+	}
+}
+
+// Parse errors should report the file and line.
+func TestLoadParseError(t *testing.T) {
+	tests := map[string]string{
+		"bad.yaml": "a: 1\nb: [\n",
+		"bad.json": "{\n  \"a\": 1,\n  \"b\": }\n",
+		"bad.toml": "a = 1\nb = \n",
+		"bad.csv":  "a,b\n1,2\n3\n",
+	}
+	expected := map[string]int{
+		"bad.yaml": 2,
+		"bad.json": 3,
+		"bad.toml": 2,
+		"bad.csv":  3,
+	}
+
+	for name, text := range tests {
+		dir := testutil.TempFiles(t, map[string]string{name: text})
+
+		_, err := data.Load(dir)
+
+		var parseErr data.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("wanted parse error for %s, got %v", name, err)
+			continue
+		}
+
+		if parseErr.Line != expected[name] {
+			t.Errorf(
+				"line for %s incorrect; wanted %d, got %d (%v)",
+				name,
+				expected[name],
+				parseErr.Line,
+				err,
+			)
+		}
+	}
+}
+
+// A file and a directory can't share a key.
+func TestLoadConflict(t *testing.T) {
+	dir := testutil.TempFiles(t, map[string]string{
+		"team.yaml":         "name: Team\n",
+		"team/members.yaml": "[]\n",
+	})
+
+	_, err := data.Load(dir)
+	if err == nil {
+		t.Error("wanted error for conflicting keys, got nil")
+	}
+}
+
+// Two files with the same key in one directory can't both be used, even with
+// layering.
+func TestLoadSameKey(t *testing.T) {
+	dir := testutil.TempFiles(t, map[string]string{
+		"team.json": `{"name": "Team"}`,
+		"team.yaml": "name: Team\n",
+	})
+
+	_, err := data.LoadLayered([]string{dir}, slog.Default())
+	if err == nil || !strings.Contains(err.Error(), "more than one file") {
+		t.Errorf("wanted error for files with the same key, got %v", err)
+	}
+}
+
+// Data files in earlier directories should override data files in later ones,
+// but files that aren't data files shouldn't override anything.
+func TestLoadLayered(t *testing.T) {
+	tmpdir := testutil.TempFiles(t, map[string]string{
+		"project/site.yaml": "title: Project\n",
+		"project/team.md":   "# Not data\n",
+		"theme/site.yaml":   "title: Theme\n",
+		"theme/team.yaml":   "name: Team\n",
+	})
+
+	var logs bytes.Buffer
+	result, err := data.LoadLayered(
+		[]string{
+			filepath.Join(tmpdir, "project"),
+			filepath.Join(tmpdir, "theme"),
+		},
+		slog.New(slog.NewTextHandler(&logs, nil)),
+	)
+	if err != nil {
+		t.Fatalf("failed to load data: %v", err)
+	}
+
+	expected := map[string]any{
+		"site": map[string]any{"title": "Project"},
+		"team": map[string]any{"name": "Team"},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("data incorrect; wanted %v, got %v", expected, result)
+	}
+
+	if !strings.Contains(logs.String(), "team.md") {
+		t.Errorf("no warning logged for team.md; got %s", logs.String())
+	}
+}
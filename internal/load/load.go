@@ -13,7 +13,9 @@ package load
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -39,6 +41,7 @@ func isDemarcationLine(line string) bool {
 type Result[TFrontmatter any] struct {
 	Frontmatter TFrontmatter // Loaded frontmatter if there was any
 	Text        string       // Main text from file
+	TextLine    int          // Line in the file where Text begins
 }
 
 type Opts struct {
@@ -68,10 +71,20 @@ func ReadFile[TFrontmatter any](
 		numDemarcationLinesSeen int
 	)
 
-	scanner := bufio.NewScanner(f)
+	// Unlike bufio.Scanner, a reader has no limit on line length (minified
+	// CSS or SVG can be very long) and keeps line endings, so the text is
+	// exactly as it is in the file.
+	reader := bufio.NewReader(f)
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	for {
+		rawLine, readErr := reader.ReadString('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return result, readErr
+		}
+		if rawLine == "" {
+			break
+		}
+		line := strings.TrimRight(rawLine, "\r\n")
 
 		demarcationAllowed := lineIndex == 0 || numDemarcationLinesSeen == 1
 		if demarcationAllowed && isDemarcationLine(line) {
@@ -96,12 +109,11 @@ func ReadFile[TFrontmatter any](
 				return result, err
 			}
 		} else { // Passed YAML block
-			_, err := textBuilder.WriteString(line)
-			if err != nil {
-				return result, err
+			if result.TextLine == 0 {
+				result.TextLine = lineIndex + 1
 			}
 
-			err = textBuilder.WriteByte('\n')
+			_, err := textBuilder.WriteString(rawLine)
 			if err != nil {
 				return result, err
 			}
@@ -117,6 +129,10 @@ func ReadFile[TFrontmatter any](
 		}
 	}
 
+	if result.TextLine == 0 {
+		result.TextLine = lineIndex + 1
+	}
+
 	result.Text = textBuilder.String()
 	return result, nil
 }
@@ -0,0 +1,90 @@
+package load_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/load"
+	"github.com/sinclairtarget/michel/internal/testutil"
+)
+
+type frontmatter struct {
+	Layout string
+}
+
+// The text after the frontmatter should be exactly as it is in the file, no
+// matter how long its lines are.
+func TestReadFile(t *testing.T) {
+	long := "<svg>" + strings.Repeat("<path d=\"M0 0\"/>", 10000) + "</svg>"
+	tests := []struct {
+		name     string
+		file     string
+		layout   string
+		text     string
+		textLine int
+	}{
+		{
+			name:     "frontmatter",
+			file:     "---\nlayout: base\n---\n<p>\n</p>\n",
+			layout:   "base",
+			text:     "<p>\n</p>\n",
+			textLine: 4,
+		},
+		{
+			name:     "long line",
+			file:     "---\nlayout: base\n---\n" + long + "\nafter",
+			layout:   "base",
+			text:     long + "\nafter",
+			textLine: 4,
+		},
+		{
+			name:     "line endings",
+			file:     "---\r\nlayout: base\r\n---\r\na\r\nb",
+			layout:   "base",
+			text:     "a\r\nb",
+			textLine: 4,
+		},
+		{
+			name:     "no frontmatter",
+			file:     "a\n---\nb\n",
+			text:     "a\n---\nb\n",
+			textLine: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := testutil.TempFiles(t, map[string]string{"f.html": test.file})
+			result, err := load.ReadFile[frontmatter](
+				filepath.Join(dir, "f.html"),
+				load.Opts{},
+			)
+			if err != nil {
+				t.Fatalf("failed to read file: %v", err)
+			}
+
+			if result.Frontmatter.Layout != test.layout {
+				t.Errorf(
+					"layout incorrect; wanted %s, got %s",
+					test.layout,
+					result.Frontmatter.Layout,
+				)
+			}
+			if result.Text != test.text {
+				t.Errorf(
+					"text incorrect; wanted %q, got %q",
+					test.text,
+					result.Text,
+				)
+			}
+			if result.TextLine != test.textLine {
+				t.Errorf(
+					"text line incorrect; wanted %d, got %d",
+					test.textLine,
+					result.TextLine,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,733 @@
+/*
+* Package mathml converts LaTeX math expressions to MathML.
+*
+* Only the subset of LaTeX commonly used in MyST math blocks is supported:
+* letters and numbers, Greek letters and other symbols, sub- and superscripts,
+* fractions, roots, accents, fonts, \left and \right delimiters, \text, and
+* matrix-like environments (matrix, pmatrix, cases, aligned, etc.).
+*
+* Unsupported commands are rendered as <merror> elements rather than failing
+* the conversion, so that a single unknown command doesn't break a build.
+ */
+package mathml
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Converts the LaTeX math expression to a <math> element.
+//
+// The original LaTeX is kept as an annotation so that it can still be copied
+// or read by assistive technology.
+func Convert(tex string, display bool) (string, error) {
+	p := parser{src: []rune(tex), display: display}
+
+	body, err := p.parseTop()
+	if err != nil {
+		return "", fmt.Errorf("failed to convert math \"%s\": %w", tex, err)
+	}
+
+	var b strings.Builder
+	if display {
+		b.WriteString(`<math display="block">`)
+	} else {
+		b.WriteString(`<math>`)
+	}
+	b.WriteString("<semantics>")
+	b.WriteString(body)
+	b.WriteString(`<annotation encoding="application/x-tex">`)
+	b.WriteString(escape(strings.TrimSpace(tex)))
+	b.WriteString("</annotation></semantics></math>")
+	return b.String(), nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF     tokenKind = iota
+	tokChar              // Any single character not listed below
+	tokCommand           // e.g. \frac
+	tokOpen              // {
+	tokClose             // }
+	tokSup               // ^
+	tokSub               // _
+	tokAlign             // &
+	tokNewline           // \\
+)
+
+type token struct {
+	kind tokenKind
+	text string // Command name or character
+}
+
+// What kind of thing a parsed item is, which determines where scripts go.
+type itemKind int
+
+const (
+	itemOrdinary itemKind = iota
+	itemLargeOp           // Limits go above and below in display mode
+	itemIntegral          // Limits are always scripts
+)
+
+type item struct {
+	xml  string
+	kind itemKind
+}
+
+type parser struct {
+	src     []rune
+	pos     int
+	display bool
+	variant string // mathvariant for identifiers, set by font commands
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(p.src[p.pos]) {
+		p.pos += 1
+	}
+}
+
+func (p *parser) peek() token {
+	pos := p.pos
+	t := p.next()
+	p.pos = pos
+	return t
+}
+
+func (p *parser) next() token {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return token{kind: tokEOF}
+	}
+
+	c := p.src[p.pos]
+	p.pos += 1
+
+	switch c {
+	case '{':
+		return token{kind: tokOpen, text: "{"}
+	case '}':
+		return token{kind: tokClose, text: "}"}
+	case '^':
+		return token{kind: tokSup, text: "^"}
+	case '_':
+		return token{kind: tokSub, text: "_"}
+	case '&':
+		return token{kind: tokAlign, text: "&"}
+	case '\\':
+		if p.pos >= len(p.src) {
+			return token{kind: tokChar, text: "\\"}
+		}
+
+		c = p.src[p.pos]
+		if c == '\\' {
+			p.pos += 1
+			return token{kind: tokNewline, text: "\\\\"}
+		}
+
+		if !isLetter(c) {
+			p.pos += 1
+			return token{kind: tokCommand, text: string(c)}
+		}
+
+		start := p.pos
+		for p.pos < len(p.src) && isLetter(p.src[p.pos]) {
+			p.pos += 1
+		}
+		return token{kind: tokCommand, text: string(p.src[start:p.pos])}
+	default:
+		return token{kind: tokChar, text: string(c)}
+	}
+}
+
+func isLetter(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// Parses the whole expression. Top-level line breaks are treated like rows of
+// an aligned environment.
+func (p *parser) parseTop() (string, error) {
+	rows, err := p.parseRows(func(t token) bool { return t.kind == tokEOF })
+	if err != nil {
+		return "", err
+	}
+
+	if len(rows) == 1 && len(rows[0]) == 1 {
+		return rows[0][0], nil
+	}
+
+	return table(rows, "aligned"), nil
+}
+
+// Parses rows of cells separated by "\\" and "&" until the stop token.
+//
+// The stop token is not consumed.
+func (p *parser) parseRows(stop func(token) bool) ([][]string, error) {
+	rows := [][]string{}
+	row := []string{}
+
+	isCellEnd := func(t token) bool {
+		return stop(t) || t.kind == tokAlign || t.kind == tokNewline
+	}
+
+	for {
+		cell, err := p.parseRow(isCellEnd)
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, cell)
+
+		t := p.peek()
+		switch t.kind {
+		case tokAlign:
+			p.next()
+		case tokNewline:
+			p.next()
+			rows = append(rows, row)
+			row = []string{}
+		default:
+			if stop(t) {
+				// Ignore a trailing "\\"
+				if len(row) > 1 || row[0] != "<mrow></mrow>" || len(rows) == 0 {
+					rows = append(rows, row)
+				}
+				return rows, nil
+			}
+
+			return nil, fmt.Errorf("unexpected \"%s\"", t.text)
+		}
+	}
+}
+
+// Parses a sequence of items until the stop token and returns them wrapped in
+// an <mrow> if necessary.
+//
+// The stop token is not consumed.
+func (p *parser) parseRow(stop func(token) bool) (string, error) {
+	items := []string{}
+	for {
+		t := p.peek()
+		if stop(t) {
+			break
+		}
+		if t.kind == tokEOF {
+			return "", fmt.Errorf("unexpected end of input")
+		}
+
+		it, err := p.parseScripted()
+		if err != nil {
+			return "", err
+		}
+		if it.xml != "" {
+			items = append(items, it.xml)
+		}
+	}
+
+	return row(items), nil
+}
+
+func row(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+
+	return "<mrow>" + strings.Join(items, "") + "</mrow>"
+}
+
+// Parses an item and any sub- or superscripts attached to it.
+func (p *parser) parseScripted() (item, error) {
+	base, err := p.parseItem()
+	if err != nil {
+		return base, err
+	}
+
+	var sub, sup string
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokSub && sub == "":
+			p.next()
+			sub, err = p.parseArg()
+		case t.kind == tokSup && sup == "":
+			p.next()
+			sup, err = p.parseArg()
+		case t.kind == tokChar && t.text == "'" && sup == "":
+			primes := ""
+			for p.peek().kind == tokChar && p.peek().text == "'" {
+				p.next()
+				primes += "′"
+			}
+			sup = "<mo>" + primes + "</mo>"
+		default:
+			return attachScripts(base, sub, sup, p.display), nil
+		}
+
+		if err != nil {
+			return base, err
+		}
+	}
+}
+
+func attachScripts(base item, sub, sup string, display bool) item {
+	if sub == "" && sup == "" {
+		return base
+	}
+
+	if base.xml == "" {
+		base.xml = "<mrow></mrow>"
+	}
+
+	under, over := "msub", "msup"
+	both := "msubsup"
+	if base.kind == itemLargeOp && display {
+		under, over, both = "munder", "mover", "munderover"
+	}
+
+	var xml string
+	switch {
+	case sub != "" && sup != "":
+		xml = fmt.Sprintf("<%s>%s%s%s</%s>", both, base.xml, sub, sup, both)
+	case sub != "":
+		xml = fmt.Sprintf("<%s>%s%s</%s>", under, base.xml, sub, under)
+	default:
+		xml = fmt.Sprintf("<%s>%s%s</%s>", over, base.xml, sup, over)
+	}
+
+	return item{xml: xml}
+}
+
+// Parses a single argument: either a braced group or a single item.
+func (p *parser) parseArg() (string, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokOpen:
+		p.next()
+		return p.parseGroup()
+	case tokEOF, tokClose:
+		return "", fmt.Errorf("missing argument")
+	default:
+		it, err := p.parseItem()
+		return it.xml, err
+	}
+}
+
+// Parses the rest of a braced group, after the opening brace.
+func (p *parser) parseGroup() (string, error) {
+	xml, err := p.parseRow(func(t token) bool { return t.kind == tokClose })
+	if err != nil {
+		return "", err
+	}
+
+	p.next() // consume "}"
+	return xml, nil
+}
+
+// Reads the raw text of a braced group, for commands like \text.
+func (p *parser) readRawGroup() (string, error) {
+	if p.next().kind != tokOpen {
+		return "", fmt.Errorf("expected \"{\"")
+	}
+
+	depth := 1
+	start := p.pos
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 1 // skip escaped character
+		case '{':
+			depth += 1
+		case '}':
+			depth -= 1
+			if depth == 0 {
+				text := string(p.src[start:p.pos])
+				p.pos += 1
+				return text, nil
+			}
+		}
+		p.pos += 1
+	}
+
+	return "", fmt.Errorf("unbalanced braces")
+}
+
+// Reads an optional argument in square brackets, e.g. the index in \sqrt[3].
+func (p *parser) parseOptionalArg() (string, bool, error) {
+	t := p.peek()
+	if t.kind != tokChar || t.text != "[" {
+		return "", false, nil
+	}
+	p.next()
+
+	xml, err := p.parseRow(func(t token) bool {
+		return t.kind == tokChar && t.text == "]"
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	p.next() // consume "]"
+	return xml, true, nil
+}
+
+func (p *parser) parseItem() (item, error) {
+	t := p.next()
+	switch t.kind {
+	case tokOpen:
+		xml, err := p.parseGroup()
+		return item{xml: xml}, err
+	case tokCommand:
+		return p.parseCommand(t.text)
+	case tokChar:
+		return p.parseChar(t.text), nil
+	case tokEOF:
+		return item{}, fmt.Errorf("unexpected end of input")
+	default:
+		return item{}, fmt.Errorf("unexpected \"%s\"", t.text)
+	}
+}
+
+func (p *parser) parseChar(c string) item {
+	r := []rune(c)[0]
+	switch {
+	case isLetter(r):
+		return item{xml: p.identifier(c)}
+	case unicode.IsDigit(r) || r == '.':
+		// Gather up the rest of the number
+		for p.pos < len(p.src) {
+			next := p.src[p.pos]
+			if !unicode.IsDigit(next) && next != '.' {
+				break
+			}
+			c += string(next)
+			p.pos += 1
+		}
+
+		if c == "." {
+			return item{xml: "<mo>.</mo>"}
+		}
+		return item{xml: "<mn>" + c + "</mn>"}
+	case c == "~":
+		return item{xml: `<mspace width="0.3333em"></mspace>`}
+	case c == "-":
+		return item{xml: "<mo>−</mo>"}
+	case c == "*":
+		return item{xml: "<mo>∗</mo>"}
+	case strings.ContainsRune("+=<>()[],;:!/|?", r):
+		return item{xml: "<mo>" + escape(c) + "</mo>"}
+	default:
+		return item{xml: p.identifier(c)}
+	}
+}
+
+func (p *parser) identifier(text string) string {
+	if p.variant != "" {
+		return fmt.Sprintf(
+			`<mi mathvariant="%s">%s</mi>`,
+			p.variant,
+			escape(text),
+		)
+	}
+
+	return "<mi>" + escape(text) + "</mi>"
+}
+
+func (p *parser) parseCommand(name string) (item, error) {
+	if s, ok := identifiers[name]; ok {
+		return item{xml: p.identifier(s)}, nil
+	}
+	if s, ok := uprightIdentifiers[name]; ok {
+		return item{xml: `<mi mathvariant="normal">` + s + "</mi>"}, nil
+	}
+	if functions[name] {
+		kind := itemOrdinary
+		if name == "lim" || name == "max" || name == "min" || name == "sup" ||
+			name == "inf" || name == "liminf" || name == "limsup" {
+			kind = itemLargeOp
+		}
+		return item{xml: "<mi>" + name + "</mi>", kind: kind}, nil
+	}
+	if s, ok := operators[name]; ok {
+		return item{xml: "<mo>" + escape(s) + "</mo>"}, nil
+	}
+	if s, ok := largeOperators[name]; ok {
+		if integrals[name] {
+			return item{xml: `<mo largeop="true">` + s + "</mo>", kind: itemIntegral}, nil
+		}
+		return item{xml: `<mo largeop="true" movablelimits="true">` + s + "</mo>", kind: itemLargeOp}, nil
+	}
+	if width, ok := spaces[name]; ok {
+		return item{xml: `<mspace width="` + width + `"></mspace>`}, nil
+	}
+	if accent, ok := accents[name]; ok {
+		arg, err := p.parseArg()
+		if err != nil {
+			return item{}, err
+		}
+		xml := fmt.Sprintf(
+			`<mover accent="true">%s<mo stretchy="true">%s</mo></mover>`,
+			arg,
+			accent,
+		)
+		return item{xml: xml}, nil
+	}
+	if accent, ok := underAccents[name]; ok {
+		arg, err := p.parseArg()
+		if err != nil {
+			return item{}, err
+		}
+		xml := fmt.Sprintf(
+			`<munder accentunder="true">%s<mo stretchy="true">%s</mo></munder>`,
+			arg,
+			accent,
+		)
+		return item{xml: xml}, nil
+	}
+	if variant, ok := fonts[name]; ok {
+		prev := p.variant
+		p.variant = variant
+		arg, err := p.parseArg()
+		p.variant = prev
+		return item{xml: arg}, err
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac", "cfrac":
+		num, err := p.parseArg()
+		if err != nil {
+			return item{}, err
+		}
+		den, err := p.parseArg()
+		if err != nil {
+			return item{}, err
+		}
+		return item{xml: "<mfrac>" + num + den + "</mfrac>"}, nil
+	case "binom":
+		n, err := p.parseArg()
+		if err != nil {
+			return item{}, err
+		}
+		k, err := p.parseArg()
+		if err != nil {
+			return item{}, err
+		}
+		xml := `<mrow><mo>(</mo><mfrac linethickness="0">` + n + k +
+			`</mfrac><mo>)</mo></mrow>`
+		return item{xml: xml}, nil
+	case "sqrt":
+		index, ok, err := p.parseOptionalArg()
+		if err != nil {
+			return item{}, err
+		}
+		arg, err := p.parseArg()
+		if err != nil {
+			return item{}, err
+		}
+		if ok {
+			return item{xml: "<mroot>" + arg + index + "</mroot>"}, nil
+		}
+		return item{xml: "<msqrt>" + arg + "</msqrt>"}, nil
+	case "text", "textrm", "textit", "textbf", "textsf", "texttt", "mbox":
+		text, err := p.readRawGroup()
+		if err != nil {
+			return item{}, err
+		}
+		return item{xml: "<mtext>" + escape(text) + "</mtext>"}, nil
+	case "operatorname":
+		text, err := p.readRawGroup()
+		if err != nil {
+			return item{}, err
+		}
+		return item{xml: `<mi mathvariant="normal">` + escape(text) + "</mi>"}, nil
+	case "overbrace", "underbrace":
+		arg, err := p.parseArg()
+		if err != nil {
+			return item{}, err
+		}
+		if name == "overbrace" {
+			xml := `<mover>` + arg + `<mo stretchy="true">⏞</mo></mover>`
+			return item{xml: xml, kind: itemLargeOp}, nil
+		}
+		xml := `<munder>` + arg + `<mo stretchy="true">⏟</mo></munder>`
+		return item{xml: xml, kind: itemLargeOp}, nil
+	case "left":
+		return p.parseFenced()
+	case "big", "Big", "bigg", "Bigg",
+		"bigl", "Bigl", "biggl", "Biggl",
+		"bigr", "Bigr", "biggr", "Biggr":
+		delim, err := p.parseDelimiter()
+		if err != nil {
+			return item{}, err
+		}
+		return item{xml: `<mo stretchy="false">` + delim + "</mo>"}, nil
+	case "begin":
+		return p.parseEnvironment()
+	case "limits", "nolimits", "displaystyle", "textstyle", "scriptstyle":
+		return item{}, nil
+	default:
+		return item{xml: "<merror><mtext>\\" + escape(name) + "</mtext></merror>"}, nil
+	}
+}
+
+// Parses the delimiter following \left, \right, \big, etc.
+func (p *parser) parseDelimiter() (string, error) {
+	t := p.next()
+	switch t.kind {
+	case tokChar:
+		if t.text == "." {
+			return "", nil // null delimiter
+		}
+		return escape(t.text), nil
+	case tokCommand:
+		if s, ok := operators[t.text]; ok {
+			return escape(s), nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid delimiter \"%s\"", t.text)
+}
+
+// Parses the rest of a \left ... \right expression.
+func (p *parser) parseFenced() (item, error) {
+	open, err := p.parseDelimiter()
+	if err != nil {
+		return item{}, err
+	}
+
+	inner, err := p.parseRow(func(t token) bool {
+		return t.kind == tokCommand && t.text == "right"
+	})
+	if err != nil {
+		return item{}, err
+	}
+	p.next() // consume \right
+
+	close, err := p.parseDelimiter()
+	if err != nil {
+		return item{}, err
+	}
+
+	var b strings.Builder
+	b.WriteString("<mrow>")
+	if open != "" {
+		b.WriteString(`<mo fence="true" stretchy="true">` + open + "</mo>")
+	}
+	b.WriteString(inner)
+	if close != "" {
+		b.WriteString(`<mo fence="true" stretchy="true">` + close + "</mo>")
+	}
+	b.WriteString("</mrow>")
+	return item{xml: b.String()}, nil
+}
+
+// Parses the rest of a \begin{...} ... \end{...} environment.
+func (p *parser) parseEnvironment() (item, error) {
+	name, err := p.readRawGroup()
+	if err != nil {
+		return item{}, err
+	}
+
+	delims, ok := matrices[name]
+	if !ok {
+		return item{}, fmt.Errorf("unsupported environment \"%s\"", name)
+	}
+
+	if name == "array" {
+		// Skip column spec
+		_, err := p.readRawGroup()
+		if err != nil {
+			return item{}, err
+		}
+	}
+
+	rows, err := p.parseRows(func(t token) bool {
+		return t.kind == tokCommand && t.text == "end"
+	})
+	if err != nil {
+		return item{}, err
+	}
+	p.next() // consume \end
+
+	end, err := p.readRawGroup()
+	if err != nil {
+		return item{}, err
+	}
+	if end != name {
+		return item{}, fmt.Errorf(
+			"\\begin{%s} ended by \\end{%s}",
+			name,
+			end,
+		)
+	}
+
+	xml := table(rows, name)
+	if delims[0] == "" && delims[1] == "" {
+		return item{xml: xml}, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("<mrow>")
+	for i, delim := range delims {
+		if i == 1 {
+			b.WriteString(xml)
+		}
+		if delim != "" {
+			b.WriteString(`<mo fence="true" stretchy="true">`)
+			b.WriteString(escape(delim))
+			b.WriteString("</mo>")
+		}
+	}
+	b.WriteString("</mrow>")
+	return item{xml: b.String()}, nil
+}
+
+// Renders rows of cells as a <mtable>.
+//
+// Aligned environments alternate right- and left-aligned columns, as in LaTeX.
+func table(rows [][]string, env string) string {
+	aligned := env == "aligned" || env == "align" || env == "align*" ||
+		env == "split"
+
+	var b strings.Builder
+	switch {
+	case aligned:
+		b.WriteString(`<mtable displaystyle="true" columnspacing="0em">`)
+	case env == "cases":
+		b.WriteString(`<mtable columnalign="left">`)
+	default:
+		b.WriteString("<mtable>")
+	}
+
+	for _, cells := range rows {
+		b.WriteString("<mtr>")
+		for i, cell := range cells {
+			if aligned {
+				align := "right"
+				if i%2 == 1 {
+					align = "left"
+				}
+				b.WriteString(`<mtd columnalign="` + align + `">`)
+			} else {
+				b.WriteString("<mtd>")
+			}
+			b.WriteString(cell)
+			b.WriteString("</mtd>")
+		}
+		b.WriteString("</mtr>")
+	}
+
+	b.WriteString("</mtable>")
+	return b.String()
+}
+
+var escaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
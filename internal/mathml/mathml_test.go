@@ -0,0 +1,120 @@
+package mathml_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/mathml"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		tex      string
+		display  bool
+		expected string // Expected contents of <semantics> before annotation
+	}{
+		{
+			name:     "identifier",
+			tex:      "x",
+			expected: "<mi>x</mi>",
+		},
+		{
+			name:     "number",
+			tex:      "3.14",
+			expected: "<mn>3.14</mn>",
+		},
+		{
+			name:     "sum",
+			tex:      "a + b = c",
+			expected: "<mrow><mi>a</mi><mo>+</mo><mi>b</mi><mo>=</mo><mi>c</mi></mrow>",
+		},
+		{
+			name:     "superscript",
+			tex:      "e^{i\\pi}",
+			expected: "<msup><mi>e</mi><mrow><mi>i</mi><mi>π</mi></mrow></msup>",
+		},
+		{
+			name:     "subsup",
+			tex:      "x_i^2",
+			expected: "<msubsup><mi>x</mi><mi>i</mi><mn>2</mn></msubsup>",
+		},
+		{
+			name:     "fraction",
+			tex:      "\\frac{1}{2}",
+			expected: "<mfrac><mn>1</mn><mn>2</mn></mfrac>",
+		},
+		{
+			name:     "root",
+			tex:      "\\sqrt[3]{x}",
+			expected: "<mroot><mi>x</mi><mn>3</mn></mroot>",
+		},
+		{
+			name:     "limits_display",
+			tex:      "\\sum_{i=1}^n i",
+			display:  true,
+			expected: `<mrow><munderover><mo largeop="true" movablelimits="true">∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></munderover><mi>i</mi></mrow>`,
+		},
+		{
+			name:     "limits_inline",
+			tex:      "\\sum_{i=1}^n i",
+			expected: `<mrow><msubsup><mo largeop="true" movablelimits="true">∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></msubsup><mi>i</mi></mrow>`,
+		},
+		{
+			name:     "text",
+			tex:      "x \\text{if } y",
+			expected: "<mrow><mi>x</mi><mtext>if </mtext><mi>y</mi></mrow>",
+		},
+		{
+			name:     "fenced",
+			tex:      "\\left( x \\right)",
+			expected: `<mrow><mo fence="true" stretchy="true">(</mo><mi>x</mi><mo fence="true" stretchy="true">)</mo></mrow>`,
+		},
+		{
+			name:     "font",
+			tex:      "\\mathbf{v}",
+			expected: `<mi mathvariant="bold">v</mi>`,
+		},
+		{
+			name:     "matrix",
+			tex:      "\\begin{matrix} a & b \\\\ c & d \\end{matrix}",
+			expected: "<mtable><mtr><mtd><mi>a</mi></mtd><mtd><mi>b</mi></mtd></mtr><mtr><mtd><mi>c</mi></mtd><mtd><mi>d</mi></mtd></mtr></mtable>",
+		},
+		{
+			name:     "unknown_command",
+			tex:      "\\foo",
+			expected: "<merror><mtext>\\foo</mtext></merror>",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := mathml.Convert(test.tex, test.display)
+			if err != nil {
+				t.Fatalf("failed to convert: %v", err)
+			}
+
+			prefix := "<math><semantics>"
+			if test.display {
+				prefix = `<math display="block"><semantics>`
+			}
+			expected := prefix + test.expected + "<annotation"
+			if !strings.HasPrefix(result, expected) {
+				t.Errorf(
+					"MathML was wrong; wanted prefix:\n%s\ngot:\n%s",
+					expected,
+					result,
+				)
+			}
+		})
+	}
+}
+
+func TestConvertUnbalanced(t *testing.T) {
+	for _, tex := range []string{"\\frac{1}{2", "x}", "\\begin{matrix} a"} {
+		_, err := mathml.Convert(tex, false)
+		if err == nil {
+			t.Errorf("expected error converting \"%s\" but got nil", tex)
+		}
+	}
+}
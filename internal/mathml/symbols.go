@@ -0,0 +1,296 @@
+package mathml
+
+// LaTeX commands rendered as identifiers (<mi>).
+var identifiers = map[string]string{
+	// Lowercase Greek
+	"alpha":      "α",
+	"beta":       "β",
+	"gamma":      "γ",
+	"delta":      "δ",
+	"epsilon":    "ϵ",
+	"varepsilon": "ε",
+	"zeta":       "ζ",
+	"eta":        "η",
+	"theta":      "θ",
+	"vartheta":   "ϑ",
+	"iota":       "ι",
+	"kappa":      "κ",
+	"lambda":     "λ",
+	"mu":         "μ",
+	"nu":         "ν",
+	"xi":         "ξ",
+	"pi":         "π",
+	"varpi":      "ϖ",
+	"rho":        "ρ",
+	"varrho":     "ϱ",
+	"sigma":      "σ",
+	"varsigma":   "ς",
+	"tau":        "τ",
+	"upsilon":    "υ",
+	"phi":        "ϕ",
+	"varphi":     "φ",
+	"chi":        "χ",
+	"psi":        "ψ",
+	"omega":      "ω",
+	// Misc
+	"ell":        "ℓ",
+	"hbar":       "ℏ",
+	"imath":      "ı",
+	"jmath":      "ȷ",
+	"wp":         "℘",
+	"Re":         "ℜ",
+	"Im":         "ℑ",
+	"aleph":      "ℵ",
+	"infty":      "∞",
+	"partial":    "∂",
+	"nabla":      "∇",
+	"emptyset":   "∅",
+	"varnothing": "∅",
+}
+
+// LaTeX commands rendered as upright identifiers.
+var uprightIdentifiers = map[string]string{
+	// Uppercase Greek
+	"Gamma":   "Γ",
+	"Delta":   "Δ",
+	"Theta":   "Θ",
+	"Lambda":  "Λ",
+	"Xi":      "Ξ",
+	"Pi":      "Π",
+	"Sigma":   "Σ",
+	"Upsilon": "Υ",
+	"Phi":     "Φ",
+	"Psi":     "Ψ",
+	"Omega":   "Ω",
+}
+
+// Named functions, rendered as upright identifiers.
+var functions = map[string]bool{
+	"arccos": true,
+	"arcsin": true,
+	"arctan": true,
+	"arg":    true,
+	"cos":    true,
+	"cosh":   true,
+	"cot":    true,
+	"coth":   true,
+	"csc":    true,
+	"deg":    true,
+	"det":    true,
+	"dim":    true,
+	"exp":    true,
+	"gcd":    true,
+	"hom":    true,
+	"inf":    true,
+	"ker":    true,
+	"lg":     true,
+	"lim":    true,
+	"liminf": true,
+	"limsup": true,
+	"ln":     true,
+	"log":    true,
+	"max":    true,
+	"min":    true,
+	"Pr":     true,
+	"sec":    true,
+	"sin":    true,
+	"sinh":   true,
+	"sup":    true,
+	"tan":    true,
+	"tanh":   true,
+}
+
+// LaTeX commands rendered as operators (<mo>).
+var operators = map[string]string{
+	// Binary operators
+	"pm":       "±",
+	"mp":       "∓",
+	"times":    "×",
+	"div":      "÷",
+	"cdot":     "⋅",
+	"ast":      "∗",
+	"star":     "⋆",
+	"circ":     "∘",
+	"bullet":   "∙",
+	"oplus":    "⊕",
+	"ominus":   "⊖",
+	"otimes":   "⊗",
+	"oslash":   "⊘",
+	"odot":     "⊙",
+	"cap":      "∩",
+	"cup":      "∪",
+	"wedge":    "∧",
+	"land":     "∧",
+	"vee":      "∨",
+	"lor":      "∨",
+	"setminus": "∖",
+	// Relations
+	"leq":            "≤",
+	"le":             "≤",
+	"geq":            "≥",
+	"ge":             "≥",
+	"neq":            "≠",
+	"ne":             "≠",
+	"ll":             "≪",
+	"gg":             "≫",
+	"approx":         "≈",
+	"sim":            "∼",
+	"simeq":          "≃",
+	"cong":           "≅",
+	"equiv":          "≡",
+	"propto":         "∝",
+	"in":             "∈",
+	"notin":          "∉",
+	"ni":             "∋",
+	"subset":         "⊂",
+	"supset":         "⊃",
+	"subseteq":       "⊆",
+	"supseteq":       "⊇",
+	"mid":            "∣",
+	"parallel":       "∥",
+	"perp":           "⊥",
+	"models":         "⊨",
+	"vdash":          "⊢",
+	"to":             "→",
+	"rightarrow":     "→",
+	"leftarrow":      "←",
+	"gets":           "←",
+	"Rightarrow":     "⇒",
+	"Leftarrow":      "⇐",
+	"leftrightarrow": "↔",
+	"Leftrightarrow": "⇔",
+	"iff":            "⟺",
+	"implies":        "⟹",
+	"mapsto":         "↦",
+	"uparrow":        "↑",
+	"downarrow":      "↓",
+	// Quantifiers and logic
+	"forall":  "∀",
+	"exists":  "∃",
+	"nexists": "∄",
+	"neg":     "¬",
+	"lnot":    "¬",
+	// Delimiters
+	"langle": "⟨",
+	"rangle": "⟩",
+	"lfloor": "⌊",
+	"rfloor": "⌋",
+	"lceil":  "⌈",
+	"rceil":  "⌉",
+	"lvert":  "|",
+	"rvert":  "|",
+	"vert":   "|",
+	"lVert":  "‖",
+	"rVert":  "‖",
+	"Vert":   "‖",
+	"{":      "{",
+	"}":      "}",
+	"|":      "‖",
+	// Punctuation
+	"ldots":    "…",
+	"dots":     "…",
+	"cdots":    "⋯",
+	"vdots":    "⋮",
+	"ddots":    "⋱",
+	"colon":    ":",
+	"prime":    "′",
+	"angle":    "∠",
+	"triangle": "△",
+	"%":        "%",
+	"$":        "$",
+	"&":        "&",
+	"#":        "#",
+	"_":        "_",
+}
+
+// Large operators. Limits are placed above and below these in display mode.
+var largeOperators = map[string]string{
+	"sum":       "∑",
+	"prod":      "∏",
+	"coprod":    "∐",
+	"bigcup":    "⋃",
+	"bigcap":    "⋂",
+	"bigvee":    "⋁",
+	"bigwedge":  "⋀",
+	"bigoplus":  "⨁",
+	"bigotimes": "⨂",
+	"int":       "∫",
+	"iint":      "∬",
+	"iiint":     "∭",
+	"oint":      "∮",
+}
+
+// Integrals take limits as scripts even in display mode.
+var integrals = map[string]bool{
+	"int":   true,
+	"iint":  true,
+	"iiint": true,
+	"oint":  true,
+}
+
+// Accents, mapped to the character placed over (or under) the base.
+var accents = map[string]string{
+	"hat":            "^",
+	"widehat":        "^",
+	"bar":            "‾",
+	"overline":       "‾",
+	"vec":            "→",
+	"dot":            "˙",
+	"ddot":           "¨",
+	"tilde":          "~",
+	"widetilde":      "~",
+	"check":          "ˇ",
+	"breve":          "˘",
+	"acute":          "´",
+	"grave":          "`",
+	"overrightarrow": "→",
+	"overleftarrow":  "←",
+}
+
+var underAccents = map[string]string{
+	"underline": "_",
+}
+
+// Spacing commands, mapped to widths.
+var spaces = map[string]string{
+	",":         "0.1667em",
+	"thinspace": "0.1667em",
+	":":         "0.2222em",
+	">":         "0.2222em",
+	";":         "0.2778em",
+	" ":         "0.3333em",
+	"quad":      "1em",
+	"qquad":     "2em",
+	"!":         "-0.1667em",
+}
+
+// Font commands, mapped to MathML mathvariant values.
+var fonts = map[string]string{
+	"mathrm":     "normal",
+	"mathbf":     "bold",
+	"mathit":     "italic",
+	"mathbb":     "double-struck",
+	"mathcal":    "script",
+	"mathscr":    "script",
+	"mathfrak":   "fraktur",
+	"mathsf":     "sans-serif",
+	"mathtt":     "monospace",
+	"boldsymbol": "bold-italic",
+}
+
+// Matrix-like environments, mapped to their surrounding delimiters.
+var matrices = map[string][2]string{
+	"matrix":   {"", ""},
+	"pmatrix":  {"(", ")"},
+	"bmatrix":  {"[", "]"},
+	"Bmatrix":  {"{", "}"},
+	"vmatrix":  {"|", "|"},
+	"Vmatrix":  {"‖", "‖"},
+	"cases":    {"{", ""},
+	"aligned":  {"", ""},
+	"align":    {"", ""},
+	"align*":   {"", ""},
+	"gathered": {"", ""},
+	"split":    {"", ""},
+	"array":    {"", ""},
+}
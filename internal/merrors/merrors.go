@@ -3,6 +3,7 @@ package merrors
 
 import (
 	"fmt"
+	"strings"
 )
 
 type SuggestError interface {
@@ -45,3 +46,167 @@ func (e NoAssociatedContentError) Suggestion() string {
 		e.PageFilepath,
 	)
 }
+
+// Raised when a reference in a content file points to a label that doesn't
+// exist.
+type UnresolvedReferenceError struct {
+	Path   string // Content file containing the reference
+	Target string // Label referenced
+	Kind   string // e.g. label
+}
+
+func (e UnresolvedReferenceError) Error() string {
+	return fmt.Sprintf(
+		"unresolved reference to %s \"%s\" in \"%s\"",
+		e.Kind,
+		e.Target,
+		e.Path,
+	)
+}
+
+func (e UnresolvedReferenceError) Suggestion() string {
+	return fmt.Sprintf(
+		"Is \"%s\" defined as a label in any content file? Is the label "+
+			"correct?",
+		e.Target,
+	)
+}
+
+// Raised when a reference or link points to content that no page renders, so
+// there is no URL to link to.
+type UnrenderedContentError struct {
+	Path       string // Content file containing the reference
+	Target     string // Label or document referenced
+	ContentKey string // Key of the content the target belongs to
+}
+
+func (e UnrenderedContentError) Error() string {
+	return fmt.Sprintf(
+		"reference to \"%s\" in \"%s\" points to content \"%s\", which is "+
+			"not rendered by any page",
+		e.Target,
+		e.Path,
+		e.ContentKey,
+	)
+}
+
+func (e UnrenderedContentError) Suggestion() string {
+	return fmt.Sprintf(
+		"Add \"content: %s\" to the frontmatter of a page to render it.",
+		e.ContentKey,
+	)
+}
+
+// Raised when the config file (or an environment variable) sets a key Michel
+// doesn't know about.
+type UnknownConfigKeyError struct {
+	Path       string
+	Line       int
+	Key        string
+	DidYouMean string // Closest known key, if any
+}
+
+func (e UnknownConfigKeyError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("unknown key \"%s\" in %s", e.Key, e.Path)
+	}
+
+	return fmt.Sprintf(
+		"unknown key \"%s\" at line %d of \"%s\"",
+		e.Key,
+		e.Line,
+		e.Path,
+	)
+}
+
+func (e UnknownConfigKeyError) Suggestion() string {
+	if e.DidYouMean != "" {
+		return fmt.Sprintf("Did you mean \"%s\"?", e.DidYouMean)
+	}
+
+	return fmt.Sprintf(
+		"Is \"%s\" spelled correctly? Site-specific values belong under "+
+			"\"params\".",
+		e.Key,
+	)
+}
+
+// Raised when a config key has a value that isn't allowed.
+type InvalidConfigValueError struct {
+	Path   string
+	Key    string
+	Value  string
+	Reason string
+	Hint   string // How to fix it
+}
+
+func (e InvalidConfigValueError) Error() string {
+	return fmt.Sprintf(
+		"invalid value \"%s\" for \"%s\" in \"%s\": %s",
+		e.Value,
+		e.Key,
+		e.Path,
+		e.Reason,
+	)
+}
+
+func (e InvalidConfigValueError) Suggestion() string {
+	return e.Hint
+}
+
+// Raised when a page or layout names a layout that doesn't exist.
+type LayoutNotFoundError struct {
+	Key        string
+	Path       string // Page or layout that named the layout
+	DidYouMean string // Closest existing layout key, if any
+}
+
+func (e LayoutNotFoundError) Error() string {
+	return fmt.Sprintf("layout \"%s\" used by \"%s\" not found", e.Key, e.Path)
+}
+
+func (e LayoutNotFoundError) Suggestion() string {
+	if e.DidYouMean != "" {
+		return fmt.Sprintf("Did you mean \"%s\"?", e.DidYouMean)
+	}
+
+	return fmt.Sprintf(
+		"Is there a file for layout \"%s\" in the layouts directory?",
+		e.Key,
+	)
+}
+
+// Raised when layouts name each other as parents in a loop.
+type LayoutCycleError struct {
+	Path  string // Layout that closes the loop
+	Chain []string
+}
+
+func (e LayoutCycleError) Error() string {
+	return fmt.Sprintf(
+		"layout \"%s\" is its own ancestor: %s",
+		e.Path,
+		strings.Join(e.Chain, " -> "),
+	)
+}
+
+func (e LayoutCycleError) Suggestion() string {
+	return "Remove the \"layout\" key from the frontmatter of one of these " +
+		"layouts."
+}
+
+// Raised when content includes itself, directly or through other content.
+type IncludeCycleError struct {
+	Chain []string // Paths of the content files, ending with the repeat
+}
+
+func (e IncludeCycleError) Error() string {
+	return fmt.Sprintf(
+		"content includes itself: %s",
+		strings.Join(e.Chain, " -> "),
+	)
+}
+
+func (e IncludeCycleError) Suggestion() string {
+	return "Remove one of the {include} directives in this chain."
+}
@@ -0,0 +1,238 @@
+/*
+* Package scaffold creates new sites, content, and pages.
+*
+* New content and pages are created from archetypes: templates for the new
+* file, looked up in the archetypes directory by the first directory in the
+* key (the section), falling back to a default:
+*
+*	archetypes/content/SECTION.md, then archetypes/content/default.md
+*	archetypes/site/SECTION.html.tmpl, then archetypes/site/default.html.tmpl
+*
+* If there is no archetype, a built-in one is used.
+*
+* Archetypes are Go templates using "[[" and "]]" as delimiters, so that page
+* archetypes can contain ordinary template actions. They are rendered with the
+* fields of Archetype.
+ */
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/sinclairtarget/michel/internal/config"
+)
+
+const (
+	leftDelim  = "[["
+	rightDelim = "]]"
+)
+
+// Built-in archetypes, and the files for a new site
+//
+//go:embed archetypes all:skeleton
+var files embed.FS
+
+// Data available to archetypes.
+type Archetype struct {
+	Key    string
+	Title  string // Derived from the key
+	Date   string // Today, as YYYY-MM-DD
+	Config config.Config
+}
+
+// Creates a content file for the key under the content directory, returning
+// its path.
+func NewContent(
+	archetypesDir string,
+	contentDir string,
+	key string,
+	c config.Config,
+	now time.Time,
+) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(contentDir, filepath.FromSlash(key)+".md")
+	archetype := lookupArchetype(
+		filepath.Join(archetypesDir, "content"),
+		key,
+		".md",
+		builtin("archetypes/content.md"),
+	)
+
+	return path, create(path, archetype, key, c, now)
+}
+
+// Creates a page file for the key under the site directory, returning its
+// path.
+func NewPage(
+	archetypesDir string,
+	siteDir string,
+	key string,
+	c config.Config,
+	now time.Time,
+) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(siteDir, filepath.FromSlash(key)+".html.tmpl")
+	archetype := lookupArchetype(
+		filepath.Join(archetypesDir, "site"),
+		key,
+		".html.tmpl",
+		builtin("archetypes/page.html.tmpl"),
+	)
+
+	return path, create(path, archetype, key, c, now)
+}
+
+// Creates a new site in the directory, with a config file, a page, a layout,
+// a partial, and some content.
+//
+// The directory must not exist or be empty.
+func NewSite(dir string, now time.Time) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("\"%s\" is not empty", dir)
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	key := filepath.Base(absDir)
+
+	return fs.WalkDir(files, "skeleton", func(
+		path string,
+		d fs.DirEntry,
+		err error,
+	) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel := strings.TrimPrefix(path, "skeleton/")
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		return create(target, builtin(path), key, config.DefaultConfig(), now)
+	})
+}
+
+// Returns the key cleaned of redundant separators and dots.
+//
+// Returns an error if the key is absolute or would put the new file outside
+// its directory.
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean(filepath.ToSlash(key))
+	switch {
+	case filepath.IsAbs(key) || strings.HasPrefix(cleaned, "/"):
+		return "", fmt.Errorf("key \"%s\" must be relative", key)
+	case cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../"):
+		return "", fmt.Errorf(
+			"key \"%s\" must name a file inside the directory",
+			key,
+		)
+	}
+
+	return cleaned, nil
+}
+
+func builtin(path string) string {
+	b, err := files.ReadFile(path)
+	if err != nil {
+		panic(fmt.Sprintf("missing built-in file \"%s\": %v", path, err))
+	}
+	return string(b)
+}
+
+// Returns the text of the archetype for the key.
+func lookupArchetype(dir string, key string, ext string, fallback string) string {
+	candidates := []string{}
+	if section, _, ok := strings.Cut(key, "/"); ok {
+		candidates = append(candidates, section)
+	}
+	candidates = append(candidates, "default")
+
+	for _, name := range candidates {
+		b, err := os.ReadFile(filepath.Join(dir, name+ext))
+		if err == nil {
+			return string(b)
+		}
+	}
+
+	return fallback
+}
+
+// Renders the archetype to a new file at the path.
+//
+// It's an error for the file to exist already.
+func create(
+	path string,
+	archetype string,
+	key string,
+	c config.Config,
+	now time.Time,
+) error {
+	_, err := os.Stat(path)
+	if err == nil {
+		return fmt.Errorf("\"%s\" already exists", path)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	tmpl, err := template.New(key).Delims(leftDelim, rightDelim).Parse(archetype)
+	if err != nil {
+		return fmt.Errorf("failed to parse archetype: %w", err)
+	}
+
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, Archetype{
+		Key:    key,
+		Title:  TitleFromKey(key),
+		Date:   now.Format("2006-01-02"),
+		Config: c,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render archetype: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
+
+// Returns a title for the key, e.g. "posts/my-first-post" -> "My First Post".
+func TitleFromKey(key string) string {
+	base := key[strings.LastIndex(key, "/")+1:]
+	words := strings.FieldsFunc(base, func(r rune) bool {
+		return r == '-' || r == '_' || r == ' '
+	})
+
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+
+	return strings.Join(words, " ")
+}
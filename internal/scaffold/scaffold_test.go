@@ -0,0 +1,176 @@
+package scaffold_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sinclairtarget/michel/internal/config"
+	"github.com/sinclairtarget/michel/internal/scaffold"
+	"github.com/sinclairtarget/michel/internal/testutil"
+)
+
+var now = time.Date(2025, 3, 14, 0, 0, 0, 0, time.Local)
+
+// A new site should have a config file and every input directory.
+func TestNewSite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "my-blog")
+
+	err := scaffold.NewSite(dir, now)
+	if err != nil {
+		t.Fatalf("failed to create site: %v", err)
+	}
+
+	for _, name := range []string{
+		config.Filename,
+		"content/hello.md",
+		"site/index.html.tmpl",
+		"layouts/_default/page.html",
+		"partials/head.html",
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("missing \"%s\" in new site: %v", name, err)
+		}
+	}
+
+	c, err := config.LoadFile(filepath.Join(dir, config.Filename))
+	if err != nil {
+		t.Fatalf("failed to load config of new site: %v", err)
+	}
+	if c.Title != "My Blog" {
+		t.Errorf("title incorrect; wanted \"My Blog\", got \"%s\"", c.Title)
+	}
+
+	err = scaffold.NewSite(dir, now)
+	if err == nil {
+		t.Error("wanted error creating site in non-empty directory")
+	}
+}
+
+// New content should use the archetype for its section if there is one,
+// rendered with the title and date.
+func TestNewContent(t *testing.T) {
+	tmpdir := t.TempDir()
+	archetypesDir := filepath.Join(tmpdir, "archetypes")
+	contentDir := filepath.Join(tmpdir, "content")
+
+	archetype := "---\ntitle: \"[[ .Title ]]\"\ndate: [[ .Date ]]\n---\n" +
+		"By [[ .Config.Title ]]. {{ not a template }}\n"
+	testutil.WriteFiles(t, archetypesDir, map[string]string{
+		"content/posts.md": archetype,
+	})
+
+	c := config.DefaultConfig()
+	path, err := scaffold.NewContent(
+		archetypesDir,
+		contentDir,
+		"posts/my-first-post",
+		c,
+		now,
+	)
+	if err != nil {
+		t.Fatalf("failed to create content: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read new content: %v", err)
+	}
+
+	expected := "---\ntitle: \"My First Post\"\ndate: 2025-03-14\n---\n" +
+		"By " + c.Title + ". {{ not a template }}\n"
+	if string(b) != expected {
+		t.Errorf("content incorrect; wanted:\n%s\ngot:\n%s", expected, b)
+	}
+
+	// Other sections get the built-in archetype
+	path, err = scaffold.NewContent(archetypesDir, contentDir, "notes/x", c, now)
+	if err != nil {
+		t.Fatalf("failed to create content: %v", err)
+	}
+	b, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read new content: %v", err)
+	}
+	if !strings.Contains(string(b), "draft: true") {
+		t.Errorf("wanted built-in archetype, got:\n%s", b)
+	}
+
+	_, err = scaffold.NewContent(archetypesDir, contentDir, "notes/x", c, now)
+	if err == nil {
+		t.Error("wanted error creating content that already exists")
+	}
+}
+
+// Keys that would put the new file outside its directory should be rejected.
+func TestNewInvalidKey(t *testing.T) {
+	tmpdir := t.TempDir()
+	archetypesDir := filepath.Join(tmpdir, "archetypes")
+	contentDir := filepath.Join(tmpdir, "content")
+	siteDir := filepath.Join(tmpdir, "site")
+	c := config.DefaultConfig()
+
+	keys := []string{
+		"/etc/passwd",
+		"../outside",
+		"posts/../../outside",
+		"posts/..",
+		"",
+	}
+	for _, key := range keys {
+		_, err := scaffold.NewContent(archetypesDir, contentDir, key, c, now)
+		if err == nil {
+			t.Errorf("wanted error creating content with key \"%s\"", key)
+		}
+
+		_, err = scaffold.NewPage(archetypesDir, siteDir, key, c, now)
+		if err == nil {
+			t.Errorf("wanted error creating page with key \"%s\"", key)
+		}
+	}
+
+	entries, _ := os.ReadDir(tmpdir)
+	if len(entries) > 0 {
+		t.Errorf("files created for invalid keys; got %v", entries)
+	}
+
+	// Keys inside the directory are cleaned
+	path, err := scaffold.NewContent(
+		archetypesDir,
+		contentDir,
+		"posts/./drafts/../x",
+		c,
+		now,
+	)
+	if err != nil {
+		t.Fatalf("failed to create content: %v", err)
+	}
+
+	expected := filepath.Join(contentDir, "posts", "x.md")
+	if path != expected {
+		t.Errorf("path incorrect; wanted %s, got %s", expected, path)
+	}
+}
+
+func TestTitleFromKey(t *testing.T) {
+	tests := map[string]string{
+		"posts/my-first-post": "My First Post",
+		"about":               "About",
+		"notes/snake_case":    "Snake Case",
+	}
+
+	for key, expected := range tests {
+		title := scaffold.TitleFromKey(key)
+		if title != expected {
+			t.Errorf(
+				"title for \"%s\" incorrect; wanted \"%s\", got \"%s\"",
+				key,
+				expected,
+				title,
+			)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+/*
+* Package search builds a search index for client-side search.
+*
+* The index is a JSON file listing every searchable document. Optionally, it
+* also includes an inverted index mapping stemmed terms to the documents that
+* contain them, so that a small static script can answer queries without
+* scanning every document.
+ */
+package search
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// A searchable document.
+type Document struct {
+	Title    string   `json:"title"`
+	URL      string   `json:"url"`
+	Headings []string `json:"headings,omitempty"`
+	Text     string   `json:"text"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// A posting in the inverted index: a document and the number of times the term
+// appears in it.
+type Posting [2]int
+
+type Index struct {
+	Documents []Document           `json:"documents"`
+	Terms     map[string][]Posting `json:"terms,omitempty"`
+}
+
+// Words too common to be worth indexing.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "with": true,
+}
+
+// Builds an index over the documents.
+//
+// If inverted is true, the index includes an inverted index of the titles,
+// headings, text, and tags of the documents.
+func NewIndex(docs []Document, inverted bool) Index {
+	index := Index{Documents: docs}
+	if !inverted {
+		return index
+	}
+
+	index.Terms = map[string][]Posting{}
+	for i, doc := range docs {
+		counts := map[string]int{}
+		fields := append([]string{doc.Title, doc.Text}, doc.Headings...)
+		fields = append(fields, doc.Tags...)
+		for _, field := range fields {
+			for _, term := range Terms(field) {
+				counts[term] += 1
+			}
+		}
+
+		for _, term := range slices.Sorted(maps.Keys(counts)) {
+			index.Terms[term] = append(
+				index.Terms[term],
+				Posting{i, counts[term]},
+			)
+		}
+	}
+
+	return index
+}
+
+// Splits the text into lowercase, stemmed terms, dropping stop words.
+//
+// Search frontends should run queries through the same process.
+func Terms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := []string{}
+	for _, word := range words {
+		if stopWords[word] {
+			continue
+		}
+
+		terms = append(terms, Stem(word))
+	}
+
+	return terms
+}
+
+// Writes the index as compact JSON.
+func (i Index) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(i)
+}
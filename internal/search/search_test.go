@@ -0,0 +1,82 @@
+package search_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/search"
+)
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"cats":           "cat",
+		"feed":           "feed",
+		"agreed":         "agre",
+		"plastered":      "plaster",
+		"motoring":       "motor",
+		"sing":           "sing",
+		"hopping":        "hop",
+		"filing":         "file",
+		"happy":          "happi",
+		"relational":     "relat",
+		"generalization": "gener",
+		"electrical":     "electr",
+		"adjustment":     "adjust",
+		"controll":       "control",
+		"running":        "run",
+	}
+
+	for word, expected := range tests {
+		result := search.Stem(word)
+		if result != expected {
+			t.Errorf(
+				"stem of \"%s\" incorrect; wanted \"%s\", got \"%s\"",
+				word,
+				expected,
+				result,
+			)
+		}
+	}
+}
+
+func TestTerms(t *testing.T) {
+	got := search.Terms("The Running of the Bulls, in 2025!")
+	want := []string{"run", "bull", "2025"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestNewIndex(t *testing.T) {
+	docs := []search.Document{
+		{Title: "Cats", URL: "/cats.html", Text: "Cats are running."},
+		{Title: "Dogs", URL: "/dogs.html", Text: "Dogs run after cats."},
+	}
+
+	index := search.NewIndex(docs, false)
+	if index.Terms != nil {
+		t.Error("expected no inverted index")
+	}
+
+	index = search.NewIndex(docs, true)
+
+	want := []search.Posting{{0, 2}, {1, 1}}
+	if !slices.Equal(index.Terms["cat"], want) {
+		t.Errorf(
+			"postings for \"cat\" incorrect; wanted %v, got %v",
+			want,
+			index.Terms["cat"],
+		)
+	}
+
+	want = []search.Posting{{0, 1}, {1, 1}}
+	if !slices.Equal(index.Terms["run"], want) {
+		t.Errorf(
+			"postings for \"run\" incorrect; wanted %v, got %v",
+			want,
+			index.Terms["run"],
+		)
+	}
+}
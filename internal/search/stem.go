@@ -0,0 +1,264 @@
+package search
+
+import (
+	"strings"
+)
+
+// Returns the stem of the lowercase English word, using the Porter stemming
+// algorithm.
+//
+// See https://tartarus.org/martin/PorterStemmer/def.txt. We use the original
+// algorithm because implementations of it are easy to find for JavaScript,
+// which matters because the search frontend has to stem queries the same way.
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+
+	s := stemmer{b: []byte(word)}
+	s.step1ab()
+	s.step1c()
+	s.step2()
+	s.step3()
+	s.step4()
+	s.step5()
+	return string(s.b)
+}
+
+type stemmer struct {
+	b []byte
+}
+
+func (s *stemmer) isConsonant(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		if i == 0 {
+			return true
+		}
+		return !s.isConsonant(i - 1)
+	default:
+		return true
+	}
+}
+
+// Returns the number of vowel-consonant sequences in b[:end].
+func (s *stemmer) measure(end int) int {
+	n := 0
+	i := 0
+	for i < end && s.isConsonant(i) {
+		i += 1
+	}
+
+	for i < end {
+		for i < end && !s.isConsonant(i) {
+			i += 1
+		}
+		if i >= end {
+			break
+		}
+
+		for i < end && s.isConsonant(i) {
+			i += 1
+		}
+		n += 1
+	}
+
+	return n
+}
+
+// Returns true if b[:end] contains a vowel.
+func (s *stemmer) hasVowel(end int) bool {
+	for i := 0; i < end; i++ {
+		if !s.isConsonant(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if b[:end] ends with a double consonant.
+func (s *stemmer) endsDoubleConsonant(end int) bool {
+	if end < 2 {
+		return false
+	}
+	return s.b[end-1] == s.b[end-2] && s.isConsonant(end-1)
+}
+
+// Returns true if b[:end] ends consonant-vowel-consonant, where the final
+// consonant is not w, x or y.
+func (s *stemmer) endsCVC(end int) bool {
+	if end < 3 {
+		return false
+	}
+	if !s.isConsonant(end-1) || s.isConsonant(end-2) || !s.isConsonant(end-3) {
+		return false
+	}
+
+	switch s.b[end-1] {
+	case 'w', 'x', 'y':
+		return false
+	default:
+		return true
+	}
+}
+
+func (s *stemmer) hasSuffix(suffix string) bool {
+	return strings.HasSuffix(string(s.b), suffix)
+}
+
+// Replaces the suffix if what comes before it has a measure greater than min.
+func (s *stemmer) replaceIfMeasure(suffix, replacement string, min int) bool {
+	if !s.hasSuffix(suffix) {
+		return false
+	}
+
+	stem := len(s.b) - len(suffix)
+	if s.measure(stem) > min {
+		s.b = append(s.b[:stem], replacement...)
+	}
+	return true
+}
+
+func (s *stemmer) step1ab() {
+	switch {
+	case s.hasSuffix("sses"):
+		s.b = s.b[:len(s.b)-2]
+	case s.hasSuffix("ies"):
+		s.b = s.b[:len(s.b)-2]
+	case s.hasSuffix("ss"):
+	case s.hasSuffix("s"):
+		s.b = s.b[:len(s.b)-1]
+	}
+
+	if s.hasSuffix("eed") {
+		if s.measure(len(s.b)-3) > 0 {
+			s.b = s.b[:len(s.b)-1]
+		}
+		return
+	}
+
+	var stem int
+	switch {
+	case s.hasSuffix("ed") && s.hasVowel(len(s.b)-2):
+		stem = len(s.b) - 2
+	case s.hasSuffix("ing") && s.hasVowel(len(s.b)-3):
+		stem = len(s.b) - 3
+	default:
+		return
+	}
+	s.b = s.b[:stem]
+
+	switch {
+	case s.hasSuffix("at"), s.hasSuffix("bl"), s.hasSuffix("iz"):
+		s.b = append(s.b, 'e')
+	case s.endsDoubleConsonant(len(s.b)):
+		switch s.b[len(s.b)-1] {
+		case 'l', 's', 'z':
+		default:
+			s.b = s.b[:len(s.b)-1]
+		}
+	case s.measure(len(s.b)) == 1 && s.endsCVC(len(s.b)):
+		s.b = append(s.b, 'e')
+	}
+}
+
+func (s *stemmer) step1c() {
+	if s.hasSuffix("y") && s.hasVowel(len(s.b)-1) {
+		s.b[len(s.b)-1] = 'i'
+	}
+}
+
+var step2Suffixes = [][2]string{
+	{"ational", "ate"},
+	{"tional", "tion"},
+	{"enci", "ence"},
+	{"anci", "ance"},
+	{"izer", "ize"},
+	{"abli", "able"},
+	{"alli", "al"},
+	{"entli", "ent"},
+	{"eli", "e"},
+	{"ousli", "ous"},
+	{"ization", "ize"},
+	{"ation", "ate"},
+	{"ator", "ate"},
+	{"alism", "al"},
+	{"iveness", "ive"},
+	{"fulness", "ful"},
+	{"ousness", "ous"},
+	{"aliti", "al"},
+	{"iviti", "ive"},
+	{"biliti", "ble"},
+}
+
+func (s *stemmer) step2() {
+	for _, pair := range step2Suffixes {
+		if s.replaceIfMeasure(pair[0], pair[1], 0) {
+			return
+		}
+	}
+}
+
+var step3Suffixes = [][2]string{
+	{"icate", "ic"},
+	{"ative", ""},
+	{"alize", "al"},
+	{"iciti", "ic"},
+	{"ical", "ic"},
+	{"ful", ""},
+	{"ness", ""},
+}
+
+func (s *stemmer) step3() {
+	for _, pair := range step3Suffixes {
+		if s.replaceIfMeasure(pair[0], pair[1], 0) {
+			return
+		}
+	}
+}
+
+var step4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+	"ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+func (s *stemmer) step4() {
+	// Longest matching suffix wins
+	match := ""
+	for _, suffix := range step4Suffixes {
+		if s.hasSuffix(suffix) && len(suffix) > len(match) {
+			match = suffix
+		}
+	}
+	if match == "" {
+		return
+	}
+
+	stem := len(s.b) - len(match)
+	if match == "ion" {
+		if stem == 0 || (s.b[stem-1] != 's' && s.b[stem-1] != 't') {
+			return
+		}
+	}
+
+	if s.measure(stem) > 1 {
+		s.b = s.b[:stem]
+	}
+}
+
+func (s *stemmer) step5() {
+	if s.hasSuffix("e") {
+		stem := len(s.b) - 1
+		m := s.measure(stem)
+		if m > 1 || (m == 1 && !s.endsCVC(stem)) {
+			s.b = s.b[:stem]
+		}
+	}
+
+	if s.measure(len(s.b)) > 1 && s.endsDoubleConsonant(len(s.b)) &&
+		s.b[len(s.b)-1] == 'l' {
+		s.b = s.b[:len(s.b)-1]
+	}
+}
@@ -18,13 +18,13 @@ import (
 	"github.com/sinclairtarget/michel/internal/build"
 )
 
-func Run(bind string, port int, outdir string) error {
-	watcher := newWatcher(
-		build.ContentDir,
-		build.LayoutsDir,
-		build.PartialsDir,
-		build.SiteDir,
-	)
+func Run(bind string, port int, outdir string, buildOpts build.Opts) error {
+	dirs, err := build.InputDirs(buildOpts)
+	if err != nil {
+		return fmt.Errorf("failed to find input directories: %w", err)
+	}
+
+	watcher := newWatcher(dirs.All()...)
 	defer watcher.close()
 
 	// Goroutine to watch for changes.
@@ -32,13 +32,13 @@ func Run(bind string, port int, outdir string) error {
 	go func() {
 		for event := range watcher.events {
 			slog.Debug("got file modified event", "path", event.path)
-			rebuild(outdir)
+			rebuild(outdir, buildOpts)
 		}
 
 		slog.Debug("goroutine exiting; watch events channel closed")
 	}()
 
-	err := watcher.start()
+	err = watcher.start()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Could not start file watcher: %v", err)
 		os.Exit(1)
@@ -53,9 +53,9 @@ func Run(bind string, port int, outdir string) error {
 	)
 }
 
-func rebuild(outdir string) {
+func rebuild(outdir string, buildOpts build.Opts) {
 	start := time.Now()
-	err := build.Build(outdir)
+	err := build.Build(outdir, buildOpts)
 	if err != nil {
 		build.PrintBuildError(err)
 	}
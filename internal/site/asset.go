@@ -3,6 +3,8 @@ package site
 import (
 	"log/slog"
 	"path/filepath"
+
+	"github.com/sinclairtarget/michel/internal/util"
 )
 
 // An asset is any file we want to be part of the built site that is not an
@@ -17,6 +19,7 @@ type AssetMetadata struct {
 	Filepath string
 	relURL   string
 	absURL   string
+	logger   *slog.Logger // For warnings; nil means slog.Default()
 }
 
 func (m AssetMetadata) Key() string { return m.key }
@@ -25,7 +28,7 @@ func (m AssetMetadata) RelURL() string { return m.relURL }
 
 func (m AssetMetadata) AbsURL() string {
 	if m.absURL == "" {
-		slog.Warn(
+		util.LoggerOrDefault(m.logger).Warn(
 			"no AbsURL for asset; did you configure baseURL?",
 			"key",
 			m.Key(),
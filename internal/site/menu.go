@@ -0,0 +1,345 @@
+package site
+
+import (
+	"maps"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sinclairtarget/michel/internal/config"
+	"github.com/sinclairtarget/michel/internal/util"
+)
+
+// The menu field in page frontmatter.
+//
+// This can be the name of a single menu, a list of menu names, or a map from
+// menu names to entry options:
+//
+//	menu: main
+//	menu: [main, footer]
+//	menu:
+//	  main:
+//	    name: About Us
+//	    parent: company
+type menuFrontmatter map[string]config.MenuEntry
+
+func (m *menuFrontmatter) UnmarshalYAML(node *yaml.Node) error {
+	*m = menuFrontmatter{}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		(*m)[node.Value] = config.MenuEntry{}
+		return nil
+	case yaml.SequenceNode:
+		names := []string{}
+		err := node.Decode(&names)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			(*m)[name] = config.MenuEntry{}
+		}
+		return nil
+	default:
+		entries := map[string]config.MenuEntry{}
+		err := node.Decode(&entries)
+		if err != nil {
+			return err
+		}
+
+		*m = entries
+		return nil
+	}
+}
+
+// An entry in a navigation menu.
+type MenuEntry struct {
+	Identifier string
+	Name       string
+	Weight     int
+	PageKey    string // Empty if the entry doesn't link to a page
+	Children   []MenuEntry
+	relURL     string
+	current    bool
+	ancestor   bool
+}
+
+func (e MenuEntry) RelURL() string { return e.relURL }
+
+// Returns true if the entry links to the page being rendered.
+func (e MenuEntry) IsCurrent() bool { return e.current }
+
+// Returns true if one of the entry's descendants links to the page being
+// rendered.
+func (e MenuEntry) IsAncestor() bool { return e.ancestor }
+
+// Returns true if the entry is the current page or an ancestor of it.
+func (e MenuEntry) IsActive() bool { return e.current || e.ancestor }
+
+func (e MenuEntry) HasChildren() bool { return len(e.Children) > 0 }
+
+// A named menu. Entries are sorted by weight, then name.
+type Menu []MenuEntry
+
+// Menus built for a site, shared by the copies of it made for each page.
+//
+// Building the menus looks at every page and logs warnings for bad entries,
+// so it's done once rather than on every call from a template.
+type menuCache struct {
+	once  sync.Once
+	menus map[string]Menu
+}
+
+// Returns all menus, keyed by name.
+//
+// Menus combine the entries declared in the config with those declared in
+// page frontmatter. Entries for the page being rendered, and their ancestors,
+// are marked.
+func (s Site) Menus() map[string]Menu {
+	var menus map[string]Menu
+	if s.menus == nil {
+		menus = s.buildMenus()
+	} else {
+		s.menus.once.Do(func() { s.menus.menus = s.buildMenus() })
+		menus = s.menus.menus
+	}
+
+	if s.currentPage == "" {
+		return menus
+	}
+
+	marked := map[string]Menu{}
+	for name, menu := range menus {
+		marked[name] = markCurrent(menu, s.currentPage)
+	}
+	return marked
+}
+
+func (s Site) buildMenus() map[string]Menu {
+	declared := map[string][]config.MenuEntry{}
+	for name, entries := range s.menuConfig {
+		declared[name] = append(declared[name], entries...)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(s.pageMetadata)) {
+		page := s.pageMetadata[key]
+		for _, name := range slices.Sorted(maps.Keys(page.MenuEntries)) {
+			entry := page.MenuEntries[name]
+			entry.Page = key
+			if entry.Weight == 0 {
+				entry.Weight = page.Weight
+			}
+			declared[name] = append(declared[name], entry)
+		}
+	}
+
+	menus := map[string]Menu{}
+	for name, entries := range declared {
+		menus[name] = s.buildMenu(name, entries)
+	}
+
+	return menus
+}
+
+func (s Site) buildMenu(name string, declared []config.MenuEntry) Menu {
+	type node struct {
+		entry  MenuEntry
+		parent string
+	}
+
+	logger := util.LoggerOrDefault(s.logger)
+
+	nodes := []*node{}
+	byID := map[string]*node{}
+	for _, d := range declared {
+		entry, ok := s.newMenuEntry(d)
+		if !ok {
+			logger.Warn(
+				"skipping menu entry for missing page",
+				"menu",
+				name,
+				"page",
+				d.Page,
+			)
+			continue
+		}
+
+		if _, ok := byID[entry.Identifier]; ok {
+			logger.Warn(
+				"duplicate menu entry",
+				"menu",
+				name,
+				"identifier",
+				entry.Identifier,
+			)
+			continue
+		}
+
+		n := &node{entry: entry, parent: d.Parent}
+		nodes = append(nodes, n)
+		byID[entry.Identifier] = n
+	}
+
+	children := map[string][]string{} // parent ID -> child IDs
+	roots := []string{}
+	for _, n := range nodes {
+		if n.parent == "" {
+			roots = append(roots, n.entry.Identifier)
+			continue
+		}
+
+		if _, ok := byID[n.parent]; !ok {
+			logger.Warn(
+				"menu entry has unknown parent",
+				"menu",
+				name,
+				"identifier",
+				n.entry.Identifier,
+				"parent",
+				n.parent,
+			)
+			roots = append(roots, n.entry.Identifier)
+			continue
+		}
+
+		children[n.parent] = append(children[n.parent], n.entry.Identifier)
+	}
+
+	// Every entry has at most one parent, so entries in a cycle are never
+	// reached from the roots and are left out.
+	reached := map[string]bool{}
+	var build func(id string) MenuEntry
+	build = func(id string) MenuEntry {
+		reached[id] = true
+		entry := byID[id].entry
+		for _, childID := range children[id] {
+			entry.Children = append(entry.Children, build(childID))
+		}
+
+		sortMenuEntries(entry.Children)
+		return entry
+	}
+
+	menu := Menu{}
+	for _, id := range roots {
+		menu = append(menu, build(id))
+	}
+
+	for _, n := range nodes {
+		if !reached[n.entry.Identifier] {
+			logger.Warn(
+				"skipping menu entry in a cycle of parents",
+				"menu",
+				name,
+				"identifier",
+				n.entry.Identifier,
+				"parent",
+				n.parent,
+			)
+		}
+	}
+
+	sortMenuEntries(menu)
+	return menu
+}
+
+// Returns the menu entry for the declaration, or false if it names a page that
+// doesn't exist.
+func (s Site) newMenuEntry(d config.MenuEntry) (MenuEntry, bool) {
+	entry := MenuEntry{
+		Identifier: d.Identifier,
+		Name:       d.Name,
+		Weight:     d.Weight,
+		PageKey:    d.Page,
+	}
+
+	if d.Page != "" {
+		page, ok := s.pageMetadata[d.Page]
+		if !ok {
+			return entry, false
+		}
+
+		entry.relURL = page.RelURL()
+		if entry.Name == "" {
+			entry.Name = filepath.Base(d.Page)
+		}
+		if entry.Identifier == "" {
+			entry.Identifier = d.Page
+		}
+	} else {
+		entry.relURL = s.menuURL(d.URL)
+		if entry.Identifier == "" {
+			entry.Identifier = d.Name
+		}
+	}
+
+	return entry, true
+}
+
+// Returns the URL for a menu entry that doesn't link to a page.
+//
+// Absolute URLs are left alone. Other URLs are treated as paths within the
+// site in the current language, keeping any trailing slash, query, and
+// fragment as written.
+func (s Site) menuURL(target string) string {
+	u, err := url.Parse(target)
+	if err != nil || u.IsAbs() || u.Host != "" {
+		return target
+	}
+
+	if strings.HasPrefix(target, "#") {
+		return target
+	}
+
+	relURL := LangRelURL(u.Path, s.language, s.baseURL)
+	if strings.HasSuffix(u.Path, "/") && !strings.HasSuffix(relURL, "/") {
+		relURL += "/"
+	}
+
+	u.Path = ""
+	return relURL + u.String()
+}
+
+// Returns a copy of the menu with the entries linking to the page with the
+// given key marked current, and their ancestors marked too.
+func markCurrent(menu Menu, key string) Menu {
+	marked := Menu{}
+	for _, entry := range menu {
+		entry.current = entry.PageKey != "" && entry.PageKey == key
+		entry.ancestor = false
+		if entry.Children != nil {
+			entry.Children = markCurrent(entry.Children, key)
+		}
+		for _, child := range entry.Children {
+			entry.ancestor = entry.ancestor || child.current || child.ancestor
+		}
+		marked = append(marked, entry)
+	}
+	return marked
+}
+
+func sortMenuEntries(entries []MenuEntry) {
+	slices.SortStableFunc(entries, func(a, b MenuEntry) int {
+		if a.Weight != b.Weight {
+			return a.Weight - b.Weight
+		}
+		if a.Name != b.Name {
+			return strings.Compare(a.Name, b.Name)
+		}
+		return strings.Compare(a.Identifier, b.Identifier)
+	})
+}
+
+// Returns a copy of the site for rendering the page with the given key.
+//
+// Menu entries linking to the page are marked current. The copy shares the
+// site's menus, so they're only built once.
+func (s Site) ForPage(key string) Site {
+	s.currentPage = key
+	return s
+}
@@ -0,0 +1,226 @@
+package site_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/config"
+	"github.com/sinclairtarget/michel/internal/site"
+	"github.com/sinclairtarget/michel/internal/testutil"
+)
+
+// Menus should combine entries from the config and page frontmatter, nest
+// them under their parents, and mark the current page and its ancestors.
+func TestMenus(t *testing.T) {
+	files := map[string]string{
+		"index.html": "home",
+		"about.html": `---
+menu: main
+weight: 20
+---
+about`,
+		"docs/install.html": `---
+menu:
+  main:
+    name: Install
+    parent: docs
+---
+install`,
+	}
+
+	tmpdir := testutil.TempFiles(t, files)
+
+	c := config.DefaultConfig()
+	c.Menus = map[string][]config.MenuEntry{
+		"main": {
+			{Name: "Home", Page: "index", Weight: 10},
+			{Name: "Docs", Identifier: "docs", URL: "docs/", Weight: 30},
+		},
+	}
+
+	s, err := site.LoadSite(tmpdir, c)
+	if err != nil {
+		t.Fatalf("failed to load site: %v", err)
+	}
+
+	menu := s.ForPage("docs/install").Menus()["main"]
+
+	names := []string{}
+	for _, entry := range menu {
+		names = append(names, entry.Name)
+	}
+	expected := []string{"Home", "about", "Docs"}
+	if len(names) != len(expected) {
+		t.Fatalf("menu entries incorrect; wanted %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("menu entries incorrect; wanted %v, got %v", expected, names)
+		}
+	}
+
+	docs := menu[2]
+	if docs.RelURL() != "/docs/" || !docs.IsAncestor() || docs.IsCurrent() {
+		t.Errorf(
+			"docs entry incorrect; got URL \"%s\", ancestor %v, current %v",
+			docs.RelURL(),
+			docs.IsAncestor(),
+			docs.IsCurrent(),
+		)
+	}
+
+	if len(docs.Children) != 1 {
+		t.Fatalf("wanted 1 child of docs entry, got %d", len(docs.Children))
+	}
+
+	install := docs.Children[0]
+	if install.RelURL() != "/docs/install.html" || !install.IsCurrent() {
+		t.Errorf(
+			"install entry incorrect; got URL \"%s\", current %v",
+			install.RelURL(),
+			install.IsCurrent(),
+		)
+	}
+
+	if menu[0].IsActive() {
+		t.Error("home entry should not be active")
+	}
+}
+
+// Menu entries that don't link to pages should keep their URLs as written,
+// within the site.
+func TestMenuURLs(t *testing.T) {
+	tests := map[string]string{
+		"docs/":                  "/docs/",
+		"docs":                   "/docs",
+		"/docs/":                 "/docs/",
+		"search?q=michel":        "/search?q=michel",
+		"docs/#install":          "/docs/#install",
+		"#top":                   "#top",
+		"https://example.com/x/": "https://example.com/x/",
+		"//example.com/x":        "//example.com/x",
+	}
+
+	tmpdir := testutil.TempFiles(t, map[string]string{"index.html": "home"})
+	for target, expected := range tests {
+		c := config.DefaultConfig()
+		c.Menus = map[string][]config.MenuEntry{
+			"main": {{Name: "Entry", URL: target}},
+		}
+
+		s, err := site.LoadSite(tmpdir, c)
+		if err != nil {
+			t.Fatalf("failed to load site: %v", err)
+		}
+
+		menu := s.Menus()["main"]
+		if len(menu) != 1 || menu[0].RelURL() != expected {
+			t.Errorf(
+				"menu URL for \"%s\" incorrect; wanted \"%s\", got %+v",
+				target,
+				expected,
+				menu,
+			)
+		}
+	}
+}
+
+// Entries whose parents form a cycle can't be placed in the menu, so they
+// should be left out with a warning.
+func TestMenuParentCycle(t *testing.T) {
+	tmpdir := testutil.TempFiles(t, map[string]string{"index.html": "home"})
+	c := config.DefaultConfig()
+	c.Menus = map[string][]config.MenuEntry{
+		"main": {
+			{Name: "Home", Page: "index"},
+			{Name: "A", URL: "a/", Parent: "B"},
+			{Name: "B", URL: "b/", Parent: "A"},
+		},
+	}
+
+	s, err := site.LoadSite(tmpdir, c)
+	if err != nil {
+		t.Fatalf("failed to load site: %v", err)
+	}
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	menu := s.WithLogger(logger).Menus()["main"]
+	if len(menu) != 1 || menu[0].Name != "Home" {
+		t.Errorf("menu incorrect; wanted just Home, got %+v", menu)
+	}
+
+	for _, id := range []string{"identifier=A", "identifier=B"} {
+		if !strings.Contains(logs.String(), id) {
+			t.Errorf("no warning logged for %s; got %s", id, logs.String())
+		}
+	}
+}
+
+// Menus should be built once per site, so warnings aren't repeated for every
+// page, and marking one page's entries shouldn't affect another's.
+func TestMenusPerPage(t *testing.T) {
+	tmpdir := testutil.TempFiles(t, map[string]string{
+		"index.html":  "home",
+		"about.html":  "---\nmenu: main\n---\nabout",
+		"team/a.html": "---\nmenu:\n  main:\n    parent: about\n---\na",
+	})
+	c := config.DefaultConfig()
+	c.Menus = map[string][]config.MenuEntry{
+		"main": {
+			{Name: "Home", Page: "index"},
+			{Name: "Missing", Page: "missing"},
+		},
+	}
+
+	s, err := site.LoadSite(tmpdir, c)
+	if err != nil {
+		t.Fatalf("failed to load site: %v", err)
+	}
+
+	var logs bytes.Buffer
+	s = s.WithLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	tests := []struct {
+		page     string
+		current  string // Name of the current top-level entry
+		ancestor string // Name of the ancestor top-level entry
+	}{
+		{"index", "Home", ""},
+		{"team/a", "", "about"},
+		{"about", "about", ""},
+		{"index", "Home", ""},
+	}
+	for _, test := range tests {
+		menu := s.ForPage(test.page).Menus()["main"]
+		for _, entry := range menu {
+			if entry.IsCurrent() != (entry.Name == test.current) {
+				t.Errorf(
+					"%s current on page %s incorrect; got %v",
+					entry.Name,
+					test.page,
+					entry.IsCurrent(),
+				)
+			}
+			if entry.IsAncestor() != (entry.Name == test.ancestor) {
+				t.Errorf(
+					"%s ancestor on page %s incorrect; got %v",
+					entry.Name,
+					test.page,
+					entry.IsAncestor(),
+				)
+			}
+		}
+	}
+
+	n := strings.Count(logs.String(), "skipping menu entry for missing page")
+	if n != 1 {
+		t.Errorf(
+			"wanted one warning for missing page, got %d: %s",
+			n,
+			logs.String(),
+		)
+	}
+}
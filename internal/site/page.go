@@ -5,13 +5,16 @@ import (
 	"os"
 	"strings"
 
+	"github.com/sinclairtarget/michel/internal/config"
 	"github.com/sinclairtarget/michel/internal/load"
 	"github.com/sinclairtarget/michel/internal/util"
 )
 
 type frontmatter struct {
-	Layouts []string // Keys naming the layouts that should be used
-	Content string   // Key naming content associated with this page
+	Layouts []string        // Keys naming the layouts that should be used
+	Content string          // Key naming content associated with this page
+	Menu    menuFrontmatter // Menus the page should appear in
+	Weight  int             // Default weight of the page's menu entries
 }
 
 // Metadata for a Michel page available on disk.
@@ -20,9 +23,14 @@ type PageMetadata struct {
 	Filepath string // source filepath for this file
 	relURL   string
 	absURL   string
+	logger   *slog.Logger // For warnings; nil means slog.Default()
 	// From frontmatter
-	Layouts    []string
-	ContentKey string
+	Layouts     []string
+	ContentKey  string
+	MenuEntries map[string]config.MenuEntry // By menu name
+	Weight      int
+	// Language the page is built in; zero if no languages configured
+	Language config.Language
 }
 
 func (m PageMetadata) Key() string { return m.key }
@@ -31,7 +39,7 @@ func (m PageMetadata) RelURL() string { return m.relURL }
 
 func (m PageMetadata) AbsURL() string {
 	if m.absURL == "" {
-		slog.Warn(
+		util.LoggerOrDefault(m.logger).Warn(
 			"no AbsURL for page; did you configure baseURL?",
 			"key",
 			m.Key(),
@@ -46,6 +54,7 @@ func (m PageMetadata) AbsURL() string {
 type Page struct {
 	PageMetadata
 	TemplateText string
+	TemplateLine int // Line in the file where the template text begins
 }
 
 func LoadPageMetadata(
@@ -88,6 +97,8 @@ func LoadPageMetadata(
 	// Load frontmatter fields
 	metadata.Layouts = result.Frontmatter.Layouts
 	metadata.ContentKey = result.Frontmatter.Content
+	metadata.MenuEntries = result.Frontmatter.Menu
+	metadata.Weight = result.Frontmatter.Weight
 
 	return metadata, nil
 }
@@ -104,6 +115,7 @@ func LoadPage(m PageMetadata) (Page, error) {
 	}
 
 	page.TemplateText = result.Text
+	page.TemplateLine = result.TextLine
 	return page, nil
 }
 
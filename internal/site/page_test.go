@@ -153,3 +153,35 @@ func TestLoadPageNoFrontmatter(t *testing.T) {
 		)
 	}
 }
+
+// An empty list of layouts should be distinguishable from no list at all, so
+// that a page can opt out of the default layout.
+func TestLoadPageEmptyLayouts(t *testing.T) {
+	tests := map[string]bool{
+		"---\nlayouts: []\n---\nhello\n":  true,
+		"---\ncontent: foo\n---\nhello\n": false,
+	}
+
+	for fileContents, expected := range tests {
+		tmpdir := t.TempDir()
+		filename := filepath.Join(tmpdir, "page.html.tmpl")
+		err := os.WriteFile(filename, []byte(fileContents), 0o644)
+		if err != nil {
+			t.Fatalf("failed to write template to tmp dir: %v", err)
+		}
+
+		metadata, err := site.LoadPageMetadata(tmpdir, filename, "")
+		if err != nil {
+			t.Fatalf("failed to load template: %v", err)
+		}
+
+		if (metadata.Layouts != nil) != expected {
+			t.Errorf(
+				"layouts incorrect for %q; wanted non-nil %t, got %#v",
+				fileContents,
+				expected,
+				metadata.Layouts,
+			)
+		}
+	}
+}
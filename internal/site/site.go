@@ -3,7 +3,9 @@ package site
 import (
 	"errors"
 	"iter"
+	"log/slog"
 	"maps"
+	"path/filepath"
 
 	"github.com/sinclairtarget/michel/internal/config"
 	"github.com/sinclairtarget/michel/internal/merrors"
@@ -13,35 +15,120 @@ import (
 type Site struct {
 	pageMetadata  map[string]PageMetadata
 	assetMetadata map[string]AssetMetadata
+	baseURL       string
+	language      config.Language
+	menuConfig    map[string][]config.MenuEntry
+	currentPage   string       // Key of page being rendered, if any
+	logger        *slog.Logger // For warnings; nil means slog.Default()
+	menus         *menuCache   // Built on first use; nil means no caching
 }
 
-func LoadSite(dir string, config config.Config) (Site, error) {
+// Loads the pages and assets under the site directory.
+//
+// Pages and assets in any theme site directories are loaded too, unless the
+// site directory (or an earlier theme) has a file with the same key.
+func LoadSite(
+	dir string,
+	config config.Config,
+	themeDirs ...string,
+) (Site, error) {
 	site := Site{
 		pageMetadata:  map[string]PageMetadata{},
 		assetMetadata: map[string]AssetMetadata{},
+		baseURL:       config.BaseURL,
+		menuConfig:    config.Menus,
+		menus:         &menuCache{},
 	}
 
-	seq, finish := util.WalkFiles(dir)
-	for path := range seq {
-		if isPagePath(path) {
-			m, err := LoadPageMetadata(dir, path, config.BaseURL)
+	files, err := util.LayeredFiles(append([]string{dir}, themeDirs...), FileKey)
+	if err != nil {
+		return site, err
+	}
+
+	for _, file := range files {
+		if isPagePath(file.Path) {
+			m, err := LoadPageMetadata(file.Dir, file.Path, config.BaseURL)
 			if err != nil {
 				return site, err
 			}
 
 			site.pageMetadata[m.Key()] = m
 		} else {
-			m := NewAsset(dir, path, config.BaseURL)
+			m := NewAsset(file.Dir, file.Path, config.BaseURL)
 			site.assetMetadata[m.Key()] = m
 		}
 	}
 
-	err := finish()
+	return site, nil
+}
+
+// Prefix of the keys FileKey returns for pages.
+const PageFileKeyPrefix = "page:"
+
+// Returns the key of the page or asset at the path under the site directory.
+//
+// Page keys are prefixed so they can't clash with asset keys. The prefix is
+// for layering only; pages are otherwise known by the key without it.
+func FileKey(dir string, path string) string {
+	if isPagePath(path) {
+		return PageFileKeyPrefix + util.KeyFromPath(dir, path)
+	}
+
+	key, err := filepath.Rel(dir, path)
 	if err != nil {
-		return site, err
+		panic("asset path could not be made relative to site directory")
+	}
+	return key
+}
+
+// Returns a copy of the site with its pages built in the given language.
+//
+// Page URLs are moved under the language's URL prefix. Only pages for which
+// include returns true are kept. Assets are shared by all languages.
+func (s Site) InLanguage(
+	lang config.Language,
+	include func(PageMetadata) bool,
+) Site {
+	pages := map[string]PageMetadata{}
+	for key, m := range s.pageMetadata {
+		if !include(m) {
+			continue
+		}
+
+		m.Language = lang
+		m.relURL = LangRelURL(key+".html", lang, s.baseURL)
+		if s.baseURL != "" {
+			m.absURL = LangAbsURL(key+".html", lang, s.baseURL)
+		}
+		pages[key] = m
 	}
 
-	return site, nil
+	s.pageMetadata = pages
+	s.language = lang
+	s.menus = &menuCache{}
+	return s
+}
+
+// Returns a copy of the site, and of every page and asset in it, that logs
+// warnings to the given logger.
+func (s Site) WithLogger(logger *slog.Logger) Site {
+	pages := map[string]PageMetadata{}
+	for key, m := range s.pageMetadata {
+		m.logger = logger
+		pages[key] = m
+	}
+
+	assets := map[string]AssetMetadata{}
+	for key, m := range s.assetMetadata {
+		m.logger = logger
+		assets[key] = m
+	}
+
+	s.pageMetadata = pages
+	s.assetMetadata = assets
+	s.logger = logger
+	s.menus = &menuCache{}
+	return s
 }
 
 // Makes calling Site.Pages.Get or Site.Assets.Get possible in templates.
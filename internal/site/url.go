@@ -2,7 +2,10 @@ package site
 
 import (
 	"net/url"
+	"path"
 	"strings"
+
+	"github.com/sinclairtarget/michel/internal/config"
 )
 
 // Returns an origin-relative URL incorporating any leading path part present
@@ -63,3 +66,22 @@ func AbsURL(suffix string, baseURL string) string {
 		return "/" + u.Path
 	}
 }
+
+// Returns an origin-relative URL for a page in the given language.
+//
+// This is the same as RelURL, except that the language's URL prefix comes
+// before the suffix.
+//
+// e.g.
+// foo/bar  de  https://bim.com/bat -> /bat/de/foo/bar
+func LangRelURL(suffix string, lang config.Language, baseURL string) string {
+	return RelURL(path.Join(lang.URLPrefix(), suffix), baseURL)
+}
+
+// Returns an absolute URL for a page in the given language.
+//
+// This is the same as AbsURL, except that the language's URL prefix comes
+// before the suffix.
+func LangAbsURL(suffix string, lang config.Language, baseURL string) string {
+	return AbsURL(path.Join(lang.URLPrefix(), suffix), baseURL)
+}
@@ -3,6 +3,7 @@ package site_test
 import (
 	"testing"
 
+	"github.com/sinclairtarget/michel/internal/config"
 	"github.com/sinclairtarget/michel/internal/site"
 )
 
@@ -93,3 +94,49 @@ func TestAbsURL(t *testing.T) {
 		})
 	}
 }
+
+func TestLangRelURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		lang     config.Language
+		expected string
+	}{
+		{
+			name:     "no_language",
+			lang:     config.Language{},
+			expected: "/bat/foo/bar",
+		},
+		{
+			name:     "code_prefix",
+			lang:     config.Language{Code: "de"},
+			expected: "/bat/de/foo/bar",
+		},
+		{
+			name:     "custom_prefix",
+			lang:     config.Language{Code: "de", Prefix: "/deutsch/"},
+			expected: "/bat/deutsch/foo/bar",
+		},
+		{
+			name:     "root_prefix",
+			lang:     config.Language{Code: "en", Prefix: "/"},
+			expected: "/bat/foo/bar",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := site.LangRelURL(
+				"foo/bar",
+				test.lang,
+				"https://bim.com/bat",
+			)
+			if result != test.expected {
+				t.Errorf(
+					"LangRelURL was wrong; wanted \"%s\" but got \"%s\"",
+					test.expected,
+					result,
+				)
+			}
+		})
+	}
+}
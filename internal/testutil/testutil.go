@@ -0,0 +1,38 @@
+// Helpers shared by tests across packages.
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Writes the files, keyed by path relative to the directory, creating any
+// parent directories needed.
+func WriteFiles(t testing.TB, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, text := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatalf("failed to create directory in tmp dir: %v", err)
+		}
+
+		err = os.WriteFile(path, []byte(text), 0o644)
+		if err != nil {
+			t.Fatalf("failed to write file to tmp dir: %v", err)
+		}
+	}
+}
+
+// Writes the files to a new temporary directory and returns its path.
+//
+// The directory is removed when the test finishes.
+func TempFiles(t testing.TB, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	WriteFiles(t, dir, files)
+	return dir
+}
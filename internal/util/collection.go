@@ -69,6 +69,12 @@ func Reject[T Keyed](
 	}
 }
 
+// Returns true if the string matches the given glob pattern, using the same
+// semantics as Select and Reject.
+func MatchGlob(pattern string, s string) bool {
+	return compileGlobRegex(pattern).MatchString(s)
+}
+
 func compileGlobRegex(pattern string) *regexp.Regexp {
 	parts := strings.Split(pattern, "*")
 
@@ -0,0 +1,60 @@
+package util
+
+import (
+	"maps"
+	"slices"
+)
+
+// A file found in one of several layered directories.
+type LayeredFile struct {
+	Key       string
+	Path      string
+	Dir       string   // Directory the file was found in
+	Overrides []string // Files with the same key in later directories
+}
+
+// Returns the files under the given directories, sorted by key.
+//
+// Where more than one directory has a file with the same key, the files in the
+// earliest directory win. A file only overrides files in later directories:
+// files with the same key in the same directory are all returned, in the order
+// they were found, so callers can handle them as they would without layering.
+// The overridden files are listed on the first of them.
+//
+// The key function returns the key for a file under a directory, usually
+// KeyFromPath. Files for which it returns an empty key are skipped.
+// Directories that don't exist are skipped.
+func LayeredFiles(
+	dirs []string,
+	key func(dir string, path string) string,
+) ([]LayeredFile, error) {
+	files := map[string][]LayeredFile{}
+	for _, dir := range dirs {
+		seq, finish := WalkFiles(dir)
+		for path := range seq {
+			k := key(dir, path)
+			if k == "" {
+				continue
+			}
+
+			found := files[k]
+			if len(found) > 0 && found[0].Dir != dir {
+				found[0].Overrides = append(found[0].Overrides, path)
+				continue
+			}
+
+			files[k] = append(found, LayeredFile{Key: k, Path: path, Dir: dir})
+		}
+
+		err := finish()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	layered := []LayeredFile{}
+	for _, k := range slices.Sorted(maps.Keys(files)) {
+		layered = append(layered, files[k]...)
+	}
+	return layered, nil
+}
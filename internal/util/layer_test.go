@@ -0,0 +1,89 @@
+package util_test
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/testutil"
+	"github.com/sinclairtarget/michel/internal/util"
+)
+
+// Files in earlier directories should override files with the same key in
+// later ones.
+func TestLayeredFiles(t *testing.T) {
+	tmpdir := testutil.TempFiles(t, map[string]string{
+		"project/base.html":   "",
+		"theme/base.tmpl":     "",
+		"theme/nav/main.html": "",
+	})
+	project := filepath.Join(tmpdir, "project")
+	theme := filepath.Join(tmpdir, "theme")
+
+	files, err := util.LayeredFiles(
+		[]string{project, filepath.Join(tmpdir, "missing"), theme},
+		util.KeyFromPath,
+	)
+	if err != nil {
+		t.Fatalf("failed to list layered files: %v", err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("wrong number of files; wanted 2, got %v", files)
+	}
+
+	base := files[0]
+	if base.Key != "base" || base.Dir != project {
+		t.Errorf("base incorrect; wanted base from project, got %+v", base)
+	}
+	expected := []string{filepath.Join(theme, "base.tmpl")}
+	if !slices.Equal(base.Overrides, expected) {
+		t.Errorf(
+			"overrides incorrect; wanted %v, got %v",
+			expected,
+			base.Overrides,
+		)
+	}
+
+	nav := files[1]
+	if nav.Key != filepath.Join("nav", "main") || nav.Dir != theme {
+		t.Errorf("nav incorrect; wanted nav/main from theme, got %+v", nav)
+	}
+}
+
+// Files with the same key in the same directory don't override one another.
+func TestLayeredFilesSameDir(t *testing.T) {
+	tmpdir := testutil.TempFiles(t, map[string]string{
+		"project/base.html": "",
+		"project/base.tmpl": "",
+		"theme/base.html":   "",
+	})
+	project := filepath.Join(tmpdir, "project")
+	theme := filepath.Join(tmpdir, "theme")
+
+	files, err := util.LayeredFiles([]string{project, theme}, util.KeyFromPath)
+	if err != nil {
+		t.Fatalf("failed to list layered files: %v", err)
+	}
+
+	paths := []string{}
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	expected := []string{
+		filepath.Join(project, "base.html"),
+		filepath.Join(project, "base.tmpl"),
+	}
+	if !slices.Equal(paths, expected) {
+		t.Fatalf("files incorrect; wanted %v, got %v", expected, paths)
+	}
+
+	overrides := []string{filepath.Join(theme, "base.html")}
+	if !slices.Equal(files[0].Overrides, overrides) {
+		t.Errorf(
+			"overrides incorrect; wanted %v, got %v",
+			overrides,
+			files[0].Overrides,
+		)
+	}
+}
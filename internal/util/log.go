@@ -0,0 +1,14 @@
+package util
+
+import "log/slog"
+
+// Returns the logger, or the default logger if it is nil.
+//
+// Lets callers pass a logger of their own (e.g. to collect warnings for a
+// build report) without requiring one.
+func LoggerOrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"strings"
+)
+
+// Returns the candidate closest to s, for "did you mean" suggestions.
+//
+// Comparison ignores case. Returns false if no candidate is close enough to be
+// a plausible typo.
+func Closest(s string, candidates []string) (string, bool) {
+	best := ""
+	bestDistance := -1
+	for _, candidate := range candidates {
+		d := EditDistance(strings.ToLower(s), strings.ToLower(candidate))
+		if bestDistance < 0 || d < bestDistance {
+			best = candidate
+			bestDistance = d
+		}
+	}
+
+	if bestDistance < 0 || bestDistance > max(2, len(s)/3) {
+		return "", false
+	}
+
+	return best, true
+}
+
+// Returns the Levenshtein distance between the two strings.
+func EditDistance(a string, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,52 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/sinclairtarget/michel/internal/util"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"kitten", "sitting", 3},
+		{"baseURL", "baseURL", 0},
+		{"titel", "title", 2},
+		{"", "abc", 3},
+	}
+
+	for _, test := range tests {
+		got := util.EditDistance(test.a, test.b)
+		if got != test.want {
+			t.Errorf(
+				"distance between \"%s\" and \"%s\" incorrect; wanted %d, got %d",
+				test.a,
+				test.b,
+				test.want,
+				got,
+			)
+		}
+	}
+}
+
+func TestClosest(t *testing.T) {
+	candidates := []string{"title", "description", "baseURL", "params"}
+
+	got, ok := util.Closest("baseUrl", candidates)
+	if !ok || got != "baseURL" {
+		t.Errorf("got \"%s\", want \"baseURL\"", got)
+	}
+
+	got, ok = util.Closest("descripton", candidates)
+	if !ok || got != "description" {
+		t.Errorf("got \"%s\", want \"description\"", got)
+	}
+
+	_, ok = util.Closest("analytics", candidates)
+	if ok {
+		t.Error("wanted no suggestion for unrelated key")
+	}
+}
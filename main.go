@@ -1,14 +1,21 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/sinclairtarget/michel/internal/build"
+	"github.com/sinclairtarget/michel/internal/check"
 	"github.com/sinclairtarget/michel/internal/config"
+	"github.com/sinclairtarget/michel/internal/content/myst"
 	"github.com/sinclairtarget/michel/internal/info"
+	"github.com/sinclairtarget/michel/internal/merrors"
+	"github.com/sinclairtarget/michel/internal/scaffold"
 	"github.com/sinclairtarget/michel/internal/server"
 )
 
@@ -20,10 +27,14 @@ type command struct {
 
 func main() {
 	subcommands := map[string]command{
-		"build":   buildCmd(),
-		"serve":   serveCmd(),
-		"config":  configCmd(),
-		"version": versionCmd(),
+		"build":     buildCmd(),
+		"serve":     serveCmd(),
+		"config":    configCmd(),
+		"check":     checkCmd(),
+		"highlight": highlightCmd(),
+		"new":       newCmd(),
+		"list":      listCmd(),
+		"version":   versionCmd(),
 	}
 
 	// handle top-level flags
@@ -43,7 +54,16 @@ func main() {
 
 		fmt.Println()
 		fmt.Println("Subcommands:")
-		for _, name := range []string{"build", "serve", "config", "version"} {
+		for _, name := range []string{
+			"build",
+			"serve",
+			"config",
+			"check",
+			"highlight",
+			"new",
+			"list",
+			"version",
+		} {
 			cmd := subcommands[name]
 
 			if name == "build" {
@@ -117,14 +137,80 @@ func configureLogging(level slog.Level) {
 	slog.Debug("logging configured", "configuredLevel", level)
 }
 
+// Loads the config, exiting with an error message if that fails.
+func loadConfig(opts build.Opts) config.Config {
+	c, err := build.LoadConfig(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+
+		var suggestErr merrors.SuggestError
+		if errors.As(err, &suggestErr) {
+			fmt.Fprintln(os.Stderr)
+			fmt.Fprintln(os.Stderr, suggestErr.Suggestion())
+		}
+		os.Exit(1)
+	}
+
+	return c
+}
+
+// Flags selecting the site, its config, and the config environment, shared by
+// the subcommands that load the config.
+type siteFlags struct {
+	source     *string
+	configPath *string
+	env        *string
+}
+
+func addSiteFlags(flagSet *flag.FlagSet) siteFlags {
+	return siteFlags{
+		source: flagSet.String("source", ".", "Root directory of site"),
+		configPath: flagSet.String(
+			"config",
+			"",
+			"Path to config file (default SOURCE/michel.yaml)",
+		),
+		env: flagSet.String(
+			"env",
+			"",
+			"Environment whose config overlay (michel.ENV.yaml) to merge",
+		),
+	}
+}
+
+// Returns build options reflecting the flags.
+func (f siteFlags) opts() build.Opts {
+	return build.Opts{
+		Source:      *f.source,
+		ConfigPath:  *f.configPath,
+		Environment: *f.env,
+	}
+}
+
 func buildCmd() command {
 	flagSet := flag.NewFlagSet("michel build", flag.ExitOnError)
 
 	outdir := flagSet.String(
 		"o",
-		build.DefaultOutputDir,
-		"Output directory for build",
+		"",
+		"Output directory for build (default SOURCE/public)",
+	)
+	checkLinks := flagSet.Bool(
+		"check",
+		false,
+		"Check internal links after building",
 	)
+	failFast := flagSet.Bool(
+		"fail-fast",
+		false,
+		"Stop at the first page or asset that fails to build",
+	)
+	reportFormat := flagSet.String(
+		"report",
+		"",
+		"Write a build report to stdout in the given format (json)",
+	)
+	sf := addSiteFlags(flagSet)
 
 	description := "Build site"
 
@@ -139,7 +225,27 @@ func buildCmd() command {
 		flagSet:     flagSet,
 		description: description,
 		run: func(args []string) {
-			err := build.Build(*outdir)
+			opts := sf.opts()
+			opts.CheckLinks = *checkLinks
+			opts.FailFast = *failFast
+
+			if *reportFormat != "" && *reportFormat != "json" {
+				fmt.Fprintf(
+					os.Stderr,
+					"Unknown report format \"%s\"; the only format is json\n",
+					*reportFormat,
+				)
+				os.Exit(1)
+			}
+
+			report, err := build.BuildReport(*outdir, opts)
+			if *reportFormat == "json" {
+				writeErr := report.WriteJSON(os.Stdout)
+				if writeErr != nil {
+					fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", writeErr)
+					os.Exit(1)
+				}
+			}
 			if err != nil {
 				build.PrintBuildError(err)
 				os.Exit(1)
@@ -153,11 +259,12 @@ func serveCmd() command {
 
 	outdir := flagSet.String(
 		"o",
-		build.DefaultOutputDir,
-		"Output directory for build",
+		"",
+		"Output directory for build (default SOURCE/public)",
 	)
 	bind := flagSet.String("bind", "127.0.0.1", "Bind address")
 	port := flagSet.Int("p", 8080, "Port for HTTP server")
+	sf := addSiteFlags(flagSet)
 
 	description := "Run local HTTP server for site"
 
@@ -173,14 +280,20 @@ func serveCmd() command {
 		description: description,
 		run: func(args []string) {
 			// Build before running server
-			err := build.Build(*outdir)
+			buildOpts := sf.opts()
+			err := build.Build(*outdir, buildOpts)
 			if err != nil {
 				build.PrintBuildError(err)
 				os.Exit(1)
 			}
 
 			// Run server
-			err = server.Run(*bind, *port, *outdir)
+			err = server.Run(
+				*bind,
+				*port,
+				build.OutputDir(*outdir, buildOpts),
+				buildOpts,
+			)
 			fmt.Fprintf(os.Stderr, "Server exited: %v\n", err)
 		},
 	}
@@ -189,25 +302,258 @@ func serveCmd() command {
 func configCmd() command {
 	flagSet := flag.NewFlagSet("michel config", flag.ExitOnError)
 
+	sf := addSiteFlags(flagSet)
+	checkOnly := flagSet.Bool(
+		"check",
+		false,
+		"Only validate the config, exiting non-zero if it is invalid",
+	)
+
 	description := "Print parsed config"
 
 	flagSet.Usage = func() {
-		fmt.Println("Usage: michel config")
+		fmt.Println("Usage: michel config [OPTIONS...]")
 		fmt.Println(description)
+		fmt.Println()
+		flagSet.PrintDefaults()
 	}
 
 	return command{
 		flagSet:     flagSet,
 		description: description,
 		run: func(args []string) {
-			c, err := config.Load()
+			c := loadConfig(sf.opts())
+			if *checkOnly {
+				fmt.Println("Config is valid.")
+				return
+			}
+
+			s := c.Dump()
+			fmt.Print(s)
+
+			if len(c.Themes) > 0 {
+				printLayeredFiles(*sf.source, c)
+			}
+		},
+	}
+}
+
+// Prints which file is used for each key when themes are layered under the
+// site, as YAML comments so the output stays valid YAML.
+func printLayeredFiles(source string, c config.Config) {
+	files, err := build.LayeredFiles(source, c)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing theme files: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println()
+	fmt.Println("# File used for each key, after layering themes:")
+	for _, file := range files {
+		fmt.Printf("#   %s/%s: %s\n", file.Kind, file.Key, file.Path)
+		for _, overridden := range file.Overrides {
+			fmt.Printf("#     overrides %s\n", overridden)
+		}
+	}
+}
+
+func newCmd() command {
+	flagSet := flag.NewFlagSet("michel new", flag.ExitOnError)
+
+	sf := addSiteFlags(flagSet)
+
+	description := "Create a new site, content, or page"
+
+	flagSet.Usage = func() {
+		fmt.Println("Usage: michel new [OPTIONS...] site DIR")
+		fmt.Println("       michel new [OPTIONS...] content KEY")
+		fmt.Println("       michel new [OPTIONS...] page KEY")
+		fmt.Println(description)
+		fmt.Println()
+		fmt.Println("Content and pages are created from archetypes, if any.")
+		fmt.Println()
+		flagSet.PrintDefaults()
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) {
+			if len(args) != 2 {
+				flagSet.Usage()
+				os.Exit(1)
+			}
+			kind, target := args[0], args[1]
+			now := time.Now()
+
+			if kind == "site" {
+				err := scaffold.NewSite(target, now)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to create site: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Printf("Created site in \"%s\".\n", target)
+				return
+			}
+
+			opts := sf.opts()
+			c := loadConfig(opts)
+			dirs, err := build.InputDirs(opts)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 				os.Exit(1)
 			}
 
-			s := c.Dump()
-			fmt.Print(s)
+			var path string
+			switch kind {
+			case "content":
+				path, err = scaffold.NewContent(
+					dirs.Archetypes,
+					dirs.Content,
+					target,
+					c,
+					now,
+				)
+			case "page":
+				path, err = scaffold.NewPage(
+					dirs.Archetypes,
+					dirs.Site,
+					target,
+					c,
+					now,
+				)
+			default:
+				fmt.Fprintf(os.Stderr, "Unknown kind \"%s\"\n", kind)
+				flagSet.Usage()
+				os.Exit(1)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", kind, err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Created \"%s\".\n", path)
+		},
+	}
+}
+
+func listCmd() command {
+	flagSet := flag.NewFlagSet("michel list", flag.ExitOnError)
+
+	outdir := flagSet.String(
+		"o",
+		"",
+		"Output directory, for target paths (default SOURCE/public)",
+	)
+	format := flagSet.String("format", "table", "Output format: table, json, csv")
+	filter := flagSet.String("filter", "", "Only list keys matching glob")
+	sf := addSiteFlags(flagSet)
+
+	description := "List pages, content, assets, layouts, or partials"
+
+	flagSet.Usage = func() {
+		fmt.Printf(
+			"Usage: michel list [OPTIONS...] %s\n",
+			strings.Join(build.ListKinds, "|"),
+		)
+		fmt.Println(description)
+		fmt.Println()
+		flagSet.PrintDefaults()
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) {
+			if len(args) != 1 {
+				flagSet.Usage()
+				os.Exit(1)
+			}
+			kind := args[0]
+
+			entries, err := build.List(kind, *filter, *outdir, sf.opts())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			err = build.WriteList(os.Stdout, kind, *format, entries)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func checkCmd() command {
+	flagSet := flag.NewFlagSet("michel check", flag.ExitOnError)
+
+	outdir := flagSet.String(
+		"o",
+		"",
+		"Output directory of build to check (default SOURCE/public)",
+	)
+	sf := addSiteFlags(flagSet)
+
+	description := "Check internal links in built site"
+
+	flagSet.Usage = func() {
+		fmt.Println("Usage: michel check [OPTIONS...]")
+		fmt.Println(description)
+		fmt.Println()
+		flagSet.PrintDefaults()
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) {
+			c := loadConfig(sf.opts())
+
+			err := check.Check(build.OutputDir(*outdir, sf.opts()), check.Opts{
+				BaseURL: c.BaseURL,
+				Allow:   c.Check.Allow,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func highlightCmd() command {
+	flagSet := flag.NewFlagSet("michel highlight", flag.ExitOnError)
+
+	style := flagSet.String("style", "github", "Name of highlighting style")
+	list := flagSet.Bool("list", false, "List available styles")
+
+	description := "Print stylesheet for syntax highlighted code"
+
+	flagSet.Usage = func() {
+		fmt.Println("Usage: michel highlight [OPTIONS...]")
+		fmt.Println(description)
+		fmt.Println()
+		flagSet.PrintDefaults()
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) {
+			if *list {
+				for _, name := range myst.HighlightStyles() {
+					fmt.Println(name)
+				}
+				return
+			}
+
+			err := myst.WriteHighlightCSS(os.Stdout, *style)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
 		},
 	}
 }